@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"zyj.com/golang-study/define"
+	"zyj.com/golang-study/pkg/tserror"
+)
+
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeProblemJSON = "application/problem+json"
+)
+
+// ProblemTypeBase 问题类型 URI 前缀，设置后 type 为 前缀/错误码，为空时为 about:blank
+var ProblemTypeBase = ""
+
+// Problem RFC 7807 问题详情，code、traceId 和 stack 为扩展字段
+type Problem struct {
+	Type     string           `json:"type"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Detail   string           `json:"detail,omitempty"`
+	Instance string           `json:"instance,omitempty"`
+	Code     tserror.RespCode `json:"code"`
+	TraceID  string           `json:"traceId,omitempty"`
+	Stack    string           `json:"stack,omitempty"`
+}
+
+// NewProblem 由响应结果创建问题详情
+func NewProblem(ctx *gin.Context, status int, result *Result) *Problem {
+	problem := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: result.Message,
+		Code:   result.Code,
+		Stack:  result.Stack,
+	}
+	if ProblemTypeBase != "" {
+		problem.Type = fmt.Sprintf("%s/%d", ProblemTypeBase, result.Code)
+	}
+	if ctx != nil && ctx.Request != nil {
+		problem.Instance = ctx.Request.URL.Path
+		problem.TraceID = ctx.GetHeader(define.HEADER_TRACE_ID_KEY)
+		if problem.TraceID == "" {
+			problem.TraceID = ctx.GetString(define.HEADER_TRACE_ID_KEY)
+		}
+	}
+	return problem
+}
+
+// WantsProblem 请求的 Accept 是否优先接受 application/problem+json，未指定或为 */* 时返回 false
+func WantsProblem(ctx *gin.Context) bool {
+	if ctx == nil || ctx.Request == nil || ctx.GetHeader("Accept") == "" {
+		return false
+	}
+	return ctx.NegotiateFormat(ContentTypeJSON, ContentTypeProblemJSON) == ContentTypeProblemJSON
+}
+
+// Render 按 Accept 输出响应：失败且客户端接受 problem+json 时输出问题详情，否则输出 Result
+func Render(ctx *gin.Context, status int, result *Result) {
+	if result.Code != tserror.CodeSuccess && WantsProblem(ctx) {
+		ctx.Header("Content-Type", ContentTypeProblemJSON)
+		ctx.JSON(status, NewProblem(ctx, status, result))
+		return
+	}
+	ctx.JSON(status, result)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"zyj.com/golang-study/define"
+	"zyj.com/golang-study/pkg/tserror"
+)
+
+func TestWantsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/problem+json, application/json;q=0.5", true},
+		{"application/json, application/problem+json;q=0.5", false},
+		{"text/html", false},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			c.Request.Header.Set("Accept", tt.accept)
+		}
+		if got := WantsProblem(c); got != tt.want {
+			t.Errorf("WantsProblem(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+	if WantsProblem(nil) {
+		t.Fatal("nil context should not want problem")
+	}
+}
+
+func TestNewProblem(t *testing.T) {
+	c := newTestContext("")
+	c.Request = httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	c.Set(define.HEADER_TRACE_ID_KEY, "t1")
+	result := &Result{Code: tserror.CodeUserNotFound, Message: "用户不存在", Stack: "stack"}
+
+	problem := NewProblem(c, http.StatusNotFound, result)
+	want := Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "用户不存在",
+		Instance: "/users/42", Code: tserror.CodeUserNotFound, TraceID: "t1", Stack: "stack"}
+	if *problem != want {
+		t.Fatalf("problem = %+v", problem)
+	}
+
+	// 请求头中的 trace id 优先，设置前缀后 type 为 前缀/错误码
+	old := ProblemTypeBase
+	t.Cleanup(func() { ProblemTypeBase = old })
+	ProblemTypeBase = "https://errors.example.com"
+	c.Request.Header.Set(define.HEADER_TRACE_ID_KEY, "t2")
+	problem = NewProblem(c, http.StatusNotFound, result)
+	if problem.Type != "https://errors.example.com/60001" || problem.TraceID != "t2" {
+		t.Fatalf("problem = %+v", problem)
+	}
+	if problem := NewProblem(nil, http.StatusNotFound, result); problem.Instance != "" || problem.TraceID != "" {
+		t.Fatalf("problem without ctx = %+v", problem)
+	}
+}
+
+func TestRender(t *testing.T) {
+	render := func(accept string, result *Result) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		c.Request.Header.Set("Accept", accept)
+		Render(c, http.StatusNotFound, result)
+		return w
+	}
+	failed := &Result{Code: tserror.CodeUserNotFound, Message: "用户不存在"}
+
+	w := render(ContentTypeProblemJSON, failed)
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != ContentTypeProblemJSON ||
+		problem.Status != 404 || problem.Code != tserror.CodeUserNotFound {
+		t.Fatalf("problem response = %d %s %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+
+	// 客户端不接受 problem+json 或成功时输出 Result
+	var result Result
+	w = render(ContentTypeJSON, failed)
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || result.Code != tserror.CodeUserNotFound {
+		t.Fatalf("result response = %s", w.Body)
+	}
+	w = render(ContentTypeProblemJSON, &Result{Code: tserror.CodeSuccess})
+	if w.Header().Get("Content-Type") == ContentTypeProblemJSON {
+		t.Fatal("success rendered as problem")
+	}
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"fmt"
+	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"sync/atomic"
+	"zyj.com/golang-study/define"
 	"zyj.com/golang-study/pkg/tserror"
 )
 
@@ -13,29 +16,78 @@ type Result struct {
 	Stack   string           `json:"stack,omitempty"`
 }
 
+// productionMode 生产模式下响应中不包含调用栈
+var productionMode atomic.Bool
+
+// SetProductionMode 开启或关闭生产模式
+func SetProductionMode(enabled bool) {
+	productionMode.Store(enabled)
+}
+
+// IsProductionMode 是否为生产模式
+func IsProductionMode() bool {
+	return productionMode.Load()
+}
+
+// NewResult 创建响应结果，错误消息使用简体中文
 func NewResult(data interface{}, err error) *Result {
+	return NewResultWithContext(nil, data, err)
+}
+
+// NewResultWithContext 创建响应结果，错误消息按请求的 Accept-Language 选择语言，ctx 为空时使用简体中文
+// 生产模式下不返回调用栈和内部错误详情，需要记录时使用 ErrorStack 和 err.Error()
+func NewResultWithContext(ctx *gin.Context, data interface{}, err error) *Result {
 	result := &Result{
 		Code: tserror.CodeSuccess,
 		Data: data,
 	}
 	if err != nil {
+		lang := RequestLanguage(ctx)
 		causeErr := errors.Cause(err)
 		var e *tserror.BizError
 		var internalError *tserror.SystemError
 		if errors.As(causeErr, &e) {
 			result.Code = e.Code
-			result.Message = e.Message
+			result.Message = localize(e.Code, e.Message, lang)
 			result.Stack = fmt.Sprintf("%+v", err)
 		} else if errors.As(causeErr, &internalError) {
+			// 内部错误详情不作为消息返回，非生产模式下只出现在调用栈中
 			result.Code = internalError.Code
-			result.Message = internalError.Detail
+			result.Message = localize(internalError.Code, internalError.Message, lang)
+			result.Stack = fmt.Sprintf("%+v", err)
 		} else {
 			//logs.Error("result err", zap.Error(err))
 			result.Code = tserror.CodeServerInternalError
-			result.Message = "未知异常"
+			result.Message = tserror.Message(tserror.CodeServerInternalError, lang)
 			result.Stack = fmt.Sprintf("%+v", err)
 		}
-
+		if IsProductionMode() {
+			result.Stack = ""
+		}
 	}
 	return result
 }
+
+// ErrorStack 错误的详细信息及调用栈，用于日志记录
+func ErrorStack(err error) string {
+	if err == nil {
+		return ""
+	}
+	return fmt.Sprintf("%+v", err)
+}
+
+// RequestLanguage 获取请求的响应语言
+func RequestLanguage(ctx *gin.Context) int {
+	if ctx == nil || ctx.Request == nil {
+		return define.LanguageSimplifiedChinese
+	}
+	return tserror.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+}
+
+// localize 消息为空或与错误码默认消息相同时，替换为对应语言的消息；自定义消息原样返回
+func localize(code tserror.RespCode, message string, lang int) string {
+	if info, ok := tserror.Lookup(code); ok && (message == "" || message == info.Message) {
+		return tserror.Message(code, lang)
+	}
+	return message
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"zyj.com/golang-study/pkg/tserror"
+)
+
+func newTestContext(acceptLanguage string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	if acceptLanguage != "" {
+		c.Request.Header.Set("Accept-Language", acceptLanguage)
+	}
+	return c
+}
+
+func TestNewResult(t *testing.T) {
+	if r := NewResult("data", nil); r.Code != tserror.CodeSuccess || r.Data != "data" || r.Message != "" {
+		t.Fatalf("success result = %+v", r)
+	}
+	// 不带 ctx 时使用简体中文
+	r := NewResult(nil, tserror.NewBizErrCode(tserror.CodeUserNotFound, ""))
+	if r.Code != tserror.CodeUserNotFound || r.Message != "用户不存在" {
+		t.Fatalf("biz result = %+v", r)
+	}
+	if r := NewResult(nil, errors.New("boom")); r.Code != tserror.CodeServerInternalError || r.Message != "系统内部错误" {
+		t.Fatalf("unknown error result = %+v", r)
+	}
+}
+
+func TestNewResultWithContextLocalizes(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		err  error
+		want string
+	}{
+		{"default message translated", "en-US", tserror.NewBizErrCode(tserror.CodeUserNotFound, ""), "User not found"},
+		{"traditional chinese", "zh-TW", tserror.NewBizErrCode(tserror.CodeUserNotFound, ""), "用戶不存在"},
+		{"custom message kept", "en", tserror.NewBizErrCode(tserror.CodeUserNotFound, "用户 42 已注销"), "用户 42 已注销"},
+		{"unknown error", "en", errors.New("boom"), "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := NewResultWithContext(newTestContext(tt.lang), nil, tt.err); r.Message != tt.want {
+				t.Fatalf("message = %q, want %q", r.Message, tt.want)
+			}
+		})
+	}
+	if r := NewResultWithContext(nil, nil, tserror.NewBizErrCode(tserror.CodeUserNotFound, "")); r.Message != "用户不存在" {
+		t.Fatalf("nil ctx message = %q", r.Message)
+	}
+}
+
+func TestNewResultSystemErrorHidesDetail(t *testing.T) {
+	t.Cleanup(func() { SetProductionMode(false) })
+	err := tserror.NewSystemErrCode(tserror.CodeDatabaseError, "", errors.New("dial tcp 10.0.0.1:3306: refused"))
+	for _, production := range []bool{false, true} {
+		SetProductionMode(production)
+		r := NewResultWithContext(newTestContext("en"), nil, err)
+		// 内部错误详情不作为消息返回，非生产模式下只出现在调用栈中
+		if r.Code != tserror.CodeDatabaseError || r.Message != "Database error" {
+			t.Fatalf("production=%v: result = %+v", production, r)
+		}
+		if hasDetail := strings.Contains(r.Stack, "10.0.0.1:3306"); hasDetail == production {
+			t.Fatalf("production=%v: stack = %q", production, r.Stack)
+		}
+	}
+}
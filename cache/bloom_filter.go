@@ -2,9 +2,18 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
 	"github.com/bits-and-blooms/bloom/v3"
 )
 
+// ErrBloomFilterClosed 过滤器已关闭
+var ErrBloomFilterClosed = errors.New("bloom filter closed")
+
 // BloomFilter 布隆过滤器接口
 type BloomFilter interface {
 	// Add 添加元素到布隆过滤器
@@ -13,13 +22,67 @@ type BloomFilter interface {
 	Exists(ctx context.Context, key string) (bool, error)
 	// BatchAdd 批量添加元素
 	BatchAdd(ctx context.Context, keys []string) error
-	// Close 关闭布隆过滤器
+	// Stats 布隆过滤器统计信息（填充率、当前误判率）
+	Stats() BloomFilterStats
+	// Close 关闭布隆过滤器，关闭后 Add/Exists/BatchAdd 返回 ErrBloomFilterClosed
 	Close() error
 }
 
+// BloomFilterStats 布隆过滤器统计信息
+type BloomFilterStats struct {
+	Capacity     uint    `json:"capacity"`     // 设计容量（预期元素数量之和）
+	Count        uint    `json:"count"`        // 估算的已添加元素数量
+	Bits         uint    `json:"bits"`         // 位（计数器）总数
+	HashCount    uint    `json:"hashCount"`    // 哈希函数个数
+	Layers       int     `json:"layers"`       // 层数，非可扩展过滤器固定为1
+	FillRatio    float64 `json:"fillRatio"`    // 估算填充率（置位比例）
+	EstimatedFPR float64 `json:"estimatedFPR"` // 按当前填充率估算的误判率
+}
+
+// estimateFPR 根据填充率估算误判率：fill^k
+func estimateFPR(fillRatio float64, k uint) float64 {
+	return math.Pow(fillRatio, float64(k))
+}
+
+// 布隆过滤器类型
+const (
+	BloomFilterMemory   = "memory"
+	BloomFilterScalable = "scalable"
+	BloomFilterCounting = "counting"
+)
+
+// 默认快照间隔
+const defaultBloomSnapshotInterval = time.Minute
+
+// BloomFilterConfig 分布式缓存内置布隆过滤器配置
+// 设置 SnapshotPath 后启动时从快照恢复，运行中定时写入快照，关闭时写入最后一次快照
+type BloomFilterConfig struct {
+	Enabled           bool          `json:"enabled"`
+	Type              string        `json:"type"` // memory/scalable/counting，为空时为 memory
+	ExpectedElements  uint          `json:"expectedElements"`
+	FalsePositiveRate float64       `json:"falsePositiveRate"`
+	SnapshotPath      string        `json:"snapshotPath"`     // 快照文件路径，为空时不持久化
+	SnapshotInterval  time.Duration `json:"snapshotInterval"` // 快照间隔，0 使用默认值
+}
+
+// newBloomFilter 按配置创建布隆过滤器
+func newBloomFilter(config BloomFilterConfig) (BloomFilter, error) {
+	switch config.Type {
+	case "", BloomFilterMemory:
+		return NewMemoryBloomFilter(config.ExpectedElements, config.FalsePositiveRate), nil
+	case BloomFilterScalable:
+		return NewScalableBloomFilter(config.ExpectedElements, config.FalsePositiveRate), nil
+	case BloomFilterCounting:
+		return NewCountingBloomFilter(config.ExpectedElements, config.FalsePositiveRate), nil
+	}
+	return nil, fmt.Errorf("unknown bloom filter type %q", config.Type)
+}
+
 // MemoryBloomFilter 内存布隆过滤器实现
 type MemoryBloomFilter struct {
-	filter *bloom.BloomFilter
+	mu       sync.RWMutex
+	filter   *bloom.BloomFilter
+	capacity uint
 }
 
 // NewMemoryBloomFilter 创建内存布隆过滤器
@@ -27,27 +90,63 @@ type MemoryBloomFilter struct {
 // falsePositiveRate: 误判率
 func NewMemoryBloomFilter(expectedElements uint, falsePositiveRate float64) BloomFilter {
 	return &MemoryBloomFilter{
-		filter: bloom.NewWithEstimates(expectedElements, falsePositiveRate),
+		filter:   bloom.NewWithEstimates(expectedElements, falsePositiveRate),
+		capacity: expectedElements,
 	}
 }
 
 func (m *MemoryBloomFilter) Add(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.filter == nil {
+		return ErrBloomFilterClosed
+	}
 	m.filter.AddString(key)
 	return nil
 }
 
 func (m *MemoryBloomFilter) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.filter == nil {
+		return false, ErrBloomFilterClosed
+	}
 	return m.filter.TestString(key), nil
 }
 
 func (m *MemoryBloomFilter) BatchAdd(ctx context.Context, keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.filter == nil {
+		return ErrBloomFilterClosed
+	}
 	for _, key := range keys {
 		m.filter.AddString(key)
 	}
 	return nil
 }
 
+func (m *MemoryBloomFilter) Stats() BloomFilterStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.filter == nil {
+		return BloomFilterStats{}
+	}
+	fill := float64(m.filter.BitSet().Count()) / float64(m.filter.Cap())
+	return BloomFilterStats{
+		Capacity:     m.capacity,
+		Count:        uint(m.filter.ApproximatedSize()),
+		Bits:         m.filter.Cap(),
+		HashCount:    m.filter.K(),
+		Layers:       1,
+		FillRatio:    fill,
+		EstimatedFPR: estimateFPR(fill, m.filter.K()),
+	}
+}
+
 func (m *MemoryBloomFilter) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.filter = nil
 	return nil
 }
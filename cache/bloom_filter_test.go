@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCountingBloomFilterRemove(t *testing.T) {
+	ctx := context.Background()
+	f := NewCountingBloomFilter(1000, 0.01)
+	f.BatchAdd(ctx, []string{"a", "b"})
+	f.Add(ctx, "a")
+
+	for _, key := range []string{"a", "b"} {
+		if ok, err := f.Exists(ctx, key); !ok || err != nil {
+			t.Fatalf("exists %s: %v %v", key, ok, err)
+		}
+	}
+	// a 添加了两次，删除一次后仍然存在
+	f.Remove(ctx, "a")
+	if ok, _ := f.Exists(ctx, "a"); !ok {
+		t.Fatal("a removed after single Remove")
+	}
+	f.Remove(ctx, "a")
+	f.Remove(ctx, "b")
+	for _, key := range []string{"a", "b"} {
+		if ok, _ := f.Exists(ctx, key); ok {
+			t.Fatalf("%s exists after Remove", key)
+		}
+	}
+	// 删除不存在的元素不影响计数
+	f.Remove(ctx, "missing")
+	if st := f.Stats(); st.Count != 0 || st.FillRatio != 0 {
+		t.Fatalf("stats after removing everything: %+v", st)
+	}
+}
+
+func TestCountingBloomFilterSaturation(t *testing.T) {
+	ctx := context.Background()
+	f := NewCountingBloomFilter(10, 0.01)
+	for i := 0; i < 300; i++ {
+		f.Add(ctx, "hot")
+	}
+	// 饱和的计数器不再减少，元素不会因为删除次数多于添加次数而漏判
+	for i := 0; i < 300; i++ {
+		f.Remove(ctx, "hot")
+	}
+	if ok, _ := f.Exists(ctx, "hot"); !ok {
+		t.Fatal("saturated counters were decremented")
+	}
+}
+
+func TestScalableBloomFilterGrowth(t *testing.T) {
+	ctx := context.Background()
+	f := NewScalableBloomFilter(100, 0.01)
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	f.BatchAdd(ctx, keys)
+
+	st := f.Stats()
+	if st.Layers < 3 {
+		t.Fatalf("layers = %d, want growth past the first layer", st.Layers)
+	}
+	if st.Capacity < st.Count {
+		t.Fatalf("capacity %d < count %d", st.Capacity, st.Count)
+	}
+	for _, key := range keys {
+		if ok, _ := f.Exists(ctx, key); !ok {
+			t.Fatalf("false negative for %s", key)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if ok, _ := f.Exists(ctx, fmt.Sprintf("other-%d", i)); ok {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / 10000; rate > 0.03 {
+		t.Fatalf("false positive rate %.4f exceeds target", rate)
+	}
+}
+
+func TestBloomFilterClosed(t *testing.T) {
+	ctx := context.Background()
+	filters := map[string]BloomFilter{
+		"memory":   NewMemoryBloomFilter(100, 0.01),
+		"scalable": NewScalableBloomFilter(100, 0.01),
+		"counting": NewCountingBloomFilter(100, 0.01),
+	}
+	for name, f := range filters {
+		t.Run(name, func(t *testing.T) {
+			f.Add(ctx, "a")
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Add(ctx, "b"); !errors.Is(err, ErrBloomFilterClosed) {
+				t.Fatalf("Add after Close: %v", err)
+			}
+			if err := f.BatchAdd(ctx, []string{"b"}); !errors.Is(err, ErrBloomFilterClosed) {
+				t.Fatalf("BatchAdd after Close: %v", err)
+			}
+			if ok, err := f.Exists(ctx, "a"); ok || !errors.Is(err, ErrBloomFilterClosed) {
+				t.Fatalf("Exists after Close: %v %v", ok, err)
+			}
+			if st := f.Stats(); st.Count != 0 {
+				t.Fatalf("Stats after Close: %+v", st)
+			}
+		})
+	}
+	counting := NewCountingBloomFilter(100, 0.01)
+	counting.Close()
+	if err := counting.Remove(ctx, "a"); !errors.Is(err, ErrBloomFilterClosed) {
+		t.Fatalf("Remove after Close: %v", err)
+	}
+}
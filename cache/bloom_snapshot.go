@@ -0,0 +1,491 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// 快照文件头：magic(4) + version(1) + kind(1) + bits(8) + hashCount(8) + capacity(8)
+const (
+	bloomSnapshotMagic   = "TSBF"
+	bloomSnapshotVersion = uint8(1)
+)
+
+// 快照对应的过滤器类型，恢复时必须与目标过滤器类型一致
+const (
+	bloomKindMemory   uint8 = 1
+	bloomKindScalable uint8 = 2
+	bloomKindCounting uint8 = 3
+)
+
+// 快照各字段的合理上限，超过时视为文件损坏，避免按损坏的长度分配内存
+const (
+	maxBloomSnapshotBits   = 1 << 32
+	maxBloomHashCount      = 64
+	maxScalableLayers      = 64
+	maxScalableGrowth      = 16
+	bloomBodyHeaderSize    = 24 // m(8) + k(8) + bitset 长度(8)
+	scalableLayerCountSize = 16 // capacity(8) + count(8)
+)
+
+var (
+	ErrBloomSnapshotMismatch = errors.New("bloom filter snapshot does not match filter")
+	ErrBloomSnapshotCorrupt  = errors.New("bloom filter snapshot corrupt")
+)
+
+// BloomFilterSnapshot 支持快照持久化的布隆过滤器
+type BloomFilterSnapshot interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// bloomSnapshotHeader 快照文件头
+type bloomSnapshotHeader struct {
+	Version   uint8
+	Kind      uint8
+	Bits      uint64
+	HashCount uint64
+	Capacity  uint64
+}
+
+func writeBloomHeader(w io.Writer, h bloomSnapshotHeader) (int64, error) {
+	if _, err := io.WriteString(w, bloomSnapshotMagic); err != nil {
+		return 0, err
+	}
+	h.Version = bloomSnapshotVersion
+	if err := binary.Write(w, binary.BigEndian, h); err != nil {
+		return int64(len(bloomSnapshotMagic)), err
+	}
+	return int64(len(bloomSnapshotMagic) + binary.Size(h)), nil
+}
+
+func readBloomHeader(r io.Reader, kind uint8) (bloomSnapshotHeader, int64, error) {
+	var h bloomSnapshotHeader
+	magic := make([]byte, len(bloomSnapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return h, 0, fmt.Errorf("%w: %v", ErrBloomSnapshotCorrupt, err)
+	}
+	if string(magic) != bloomSnapshotMagic {
+		return h, int64(len(magic)), fmt.Errorf("%w: bad magic %q", ErrBloomSnapshotCorrupt, magic)
+	}
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return h, int64(len(magic)), fmt.Errorf("%w: %v", ErrBloomSnapshotCorrupt, err)
+	}
+	n := int64(len(magic) + binary.Size(h))
+	if h.Version != bloomSnapshotVersion {
+		return h, n, fmt.Errorf("%w: unsupported version %d", ErrBloomSnapshotMismatch, h.Version)
+	}
+	if h.Kind != kind {
+		return h, n, fmt.Errorf("%w: kind %d, want %d", ErrBloomSnapshotMismatch, h.Kind, kind)
+	}
+	if h.Bits == 0 || h.Bits > maxBloomSnapshotBits || h.HashCount == 0 || h.HashCount > maxBloomHashCount {
+		return h, n, fmt.Errorf("%w: m=%d k=%d", ErrBloomSnapshotCorrupt, h.Bits, h.HashCount)
+	}
+	return h, n, nil
+}
+
+// remaining 剩余可读字节数，仅 io.LimitedReader 和 bytes.Reader 等已知长度的 Reader 可用
+func remaining(r io.Reader) (int64, bool) {
+	switch x := r.(type) {
+	case *io.LimitedReader:
+		return x.N, true
+	case interface{ Len() int }:
+		return int64(x.Len()), true
+	}
+	return 0, false
+}
+
+// readExact 读取 n 个字节，已知剩余长度不足时直接返回错误，未知时按实际读到的数据增长缓冲区
+func readExact(r io.Reader, n uint64) ([]byte, error) {
+	if rem, ok := remaining(r); ok && uint64(rem) < n {
+		return nil, fmt.Errorf("%w: need %d bytes, %d left", ErrBloomSnapshotCorrupt, n, rem)
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBloomSnapshotCorrupt, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// checkTrailing 已知剩余长度时确认快照后没有多余数据
+func checkTrailing(r io.Reader) error {
+	if rem, ok := remaining(r); ok && rem != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrBloomSnapshotCorrupt, rem)
+	}
+	return nil
+}
+
+// readBloomBody 读取 bloom 库格式的过滤器：m、k、bitset 长度及 bitset 数据，m 不超过 maxBits
+func readBloomBody(r io.Reader, maxBits uint64) (*bloom.BloomFilter, int64, error) {
+	head, err := readExact(r, bloomBodyHeaderSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	m := binary.BigEndian.Uint64(head[0:8])
+	k := binary.BigEndian.Uint64(head[8:16])
+	length := binary.BigEndian.Uint64(head[16:24])
+	if m == 0 || m > maxBits || k == 0 || k > maxBloomHashCount || length != m {
+		return nil, bloomBodyHeaderSize, fmt.Errorf("%w: body m=%d k=%d length=%d", ErrBloomSnapshotCorrupt, m, k, length)
+	}
+	words, err := readExact(r, (m+63)/64*8)
+	if err != nil {
+		return nil, bloomBodyHeaderSize, err
+	}
+	filter := &bloom.BloomFilter{}
+	if _, err := filter.ReadFrom(io.MultiReader(bytes.NewReader(head), bytes.NewReader(words))); err != nil {
+		return nil, bloomBodyHeaderSize + int64(len(words)), err
+	}
+	return filter, bloomBodyHeaderSize + int64(len(words)), nil
+}
+
+// WriteTo 将过滤器写入快照，持锁复制后再写入，写磁盘期间不阻塞 Add
+func (m *MemoryBloomFilter) WriteTo(w io.Writer) (int64, error) {
+	m.mu.RLock()
+	if m.filter == nil {
+		m.mu.RUnlock()
+		return 0, ErrBloomFilterClosed
+	}
+	filter, capacity := m.filter.Copy(), m.capacity
+	m.mu.RUnlock()
+
+	n, err := writeBloomHeader(w, bloomSnapshotHeader{
+		Kind:      bloomKindMemory,
+		Bits:      uint64(filter.Cap()),
+		HashCount: uint64(filter.K()),
+		Capacity:  uint64(capacity),
+	})
+	if err != nil {
+		return n, err
+	}
+	bn, err := filter.WriteTo(w)
+	return n + bn, err
+}
+
+// ReadFrom 从快照恢复过滤器，替换当前内容
+// 快照的位数、哈希函数个数或容量与当前过滤器（即配置的 ExpectedElements/FalsePositiveRate）不同时返回 ErrBloomSnapshotMismatch
+func (m *MemoryBloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	h, n, err := readBloomHeader(r, bloomKindMemory)
+	if err != nil {
+		return n, err
+	}
+	filter, bn, err := readBloomBody(r, h.Bits)
+	n += bn
+	if err != nil {
+		return n, err
+	}
+	if uint64(filter.Cap()) != h.Bits || uint64(filter.K()) != h.HashCount {
+		return n, fmt.Errorf("%w: header m=%d k=%d, body m=%d k=%d",
+			ErrBloomSnapshotCorrupt, h.Bits, h.HashCount, filter.Cap(), filter.K())
+	}
+	if err := checkTrailing(r); err != nil {
+		return n, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.filter == nil {
+		return n, ErrBloomFilterClosed
+	}
+	if uint64(m.filter.Cap()) != h.Bits || uint64(m.filter.K()) != h.HashCount || uint64(m.capacity) != h.Capacity {
+		return n, fmt.Errorf("%w: snapshot m=%d k=%d capacity=%d, filter m=%d k=%d capacity=%d", ErrBloomSnapshotMismatch,
+			h.Bits, h.HashCount, h.Capacity, m.filter.Cap(), m.filter.K(), m.capacity)
+	}
+	m.filter = filter
+	m.capacity = uint(h.Capacity)
+	return n, nil
+}
+
+// scalableSnapshotMeta 可扩展过滤器的额外元数据
+type scalableSnapshotMeta struct {
+	Layers     uint32
+	Growth     uint32
+	FPR        float64
+	Tightening float64
+}
+
+// WriteTo 将过滤器写入快照，文件头记录总位数及最新层的哈希函数个数
+// 持锁复制各层后再写入，写磁盘期间不阻塞 Add
+func (s *ScalableBloomFilter) WriteTo(w io.Writer) (int64, error) {
+	s.mu.RLock()
+	if len(s.layers) == 0 {
+		s.mu.RUnlock()
+		return 0, ErrBloomFilterClosed
+	}
+	layers := make([]*scalableLayer, len(s.layers))
+	for i, layer := range s.layers {
+		layers[i] = &scalableLayer{filter: layer.filter.Copy(), capacity: layer.capacity, count: layer.count}
+	}
+	meta := scalableSnapshotMeta{
+		Layers:     uint32(len(s.layers)),
+		Growth:     uint32(s.growth),
+		FPR:        s.fpr,
+		Tightening: s.tightening,
+	}
+	s.mu.RUnlock()
+
+	h := bloomSnapshotHeader{Kind: bloomKindScalable}
+	for _, layer := range layers {
+		h.Bits += uint64(layer.filter.Cap())
+		h.Capacity += uint64(layer.capacity)
+		h.HashCount = uint64(layer.filter.K())
+	}
+	n, err := writeBloomHeader(w, h)
+	if err != nil {
+		return n, err
+	}
+	if err := binary.Write(w, binary.BigEndian, meta); err != nil {
+		return n, err
+	}
+	n += int64(binary.Size(meta))
+	for _, layer := range layers {
+		if err := binary.Write(w, binary.BigEndian, [2]uint64{uint64(layer.capacity), uint64(layer.count)}); err != nil {
+			return n, err
+		}
+		n += 16
+		bn, err := layer.filter.WriteTo(w)
+		n += bn
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReadFrom 从快照恢复过滤器，替换当前内容
+// 快照第一层的容量、位数、哈希函数个数或增长参数与当前过滤器不同时返回 ErrBloomSnapshotMismatch
+func (s *ScalableBloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	h, n, err := readBloomHeader(r, bloomKindScalable)
+	if err != nil {
+		return n, err
+	}
+	var meta scalableSnapshotMeta
+	if err := binary.Read(r, binary.BigEndian, &meta); err != nil {
+		return n, fmt.Errorf("%w: %v", ErrBloomSnapshotCorrupt, err)
+	}
+	n += int64(binary.Size(meta))
+	if meta.Layers == 0 || meta.Layers > maxScalableLayers || meta.Growth == 0 || meta.Growth > maxScalableGrowth ||
+		!(meta.FPR > 0 && meta.FPR < 1) || !(meta.Tightening > 0 && meta.Tightening < 1) {
+		return n, fmt.Errorf("%w: layers=%d growth=%d fpr=%g tightening=%g",
+			ErrBloomSnapshotCorrupt, meta.Layers, meta.Growth, meta.FPR, meta.Tightening)
+	}
+	layers := make([]*scalableLayer, 0, meta.Layers)
+	var bits uint64
+	for i := uint32(0); i < meta.Layers; i++ {
+		raw, err := readExact(r, scalableLayerCountSize)
+		if err != nil {
+			return n, err
+		}
+		n += scalableLayerCountSize
+		capacity, count := binary.BigEndian.Uint64(raw[0:8]), binary.BigEndian.Uint64(raw[8:16])
+		if capacity == 0 {
+			return n, fmt.Errorf("%w: layer %d has zero capacity", ErrBloomSnapshotCorrupt, i)
+		}
+		// 各层位数之和不能超过文件头记录的总位数
+		filter, bn, err := readBloomBody(r, h.Bits-bits)
+		n += bn
+		if err != nil {
+			return n, err
+		}
+		bits += uint64(filter.Cap())
+		layers = append(layers, &scalableLayer{filter: filter, capacity: uint(capacity), count: uint(count)})
+	}
+	if bits != h.Bits {
+		return n, fmt.Errorf("%w: header m=%d, body m=%d", ErrBloomSnapshotCorrupt, h.Bits, bits)
+	}
+	if err := checkTrailing(r); err != nil {
+		return n, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.layers) == 0 {
+		return n, ErrBloomFilterClosed
+	}
+	first, restored := s.layers[0], layers[0]
+	if first.capacity != restored.capacity || first.filter.Cap() != restored.filter.Cap() ||
+		first.filter.K() != restored.filter.K() || uint32(s.growth) != meta.Growth || s.tightening != meta.Tightening {
+		return n, fmt.Errorf("%w: snapshot first layer capacity=%d m=%d k=%d, filter capacity=%d m=%d k=%d", ErrBloomSnapshotMismatch,
+			restored.capacity, restored.filter.Cap(), restored.filter.K(), first.capacity, first.filter.Cap(), first.filter.K())
+	}
+	s.layers = layers
+	s.growth = uint(meta.Growth)
+	s.fpr = meta.FPR
+	s.tightening = meta.Tightening
+	return n, nil
+}
+
+// WriteTo 将过滤器写入快照，持锁复制计数器后再写入，写磁盘期间不阻塞 Add/Remove
+func (c *CountingBloomFilter) WriteTo(w io.Writer) (int64, error) {
+	c.mu.RLock()
+	if c.m == 0 {
+		c.mu.RUnlock()
+		return 0, ErrBloomFilterClosed
+	}
+	h := bloomSnapshotHeader{
+		Kind:      bloomKindCounting,
+		Bits:      uint64(c.m),
+		HashCount: uint64(c.k),
+		Capacity:  uint64(c.capacity),
+	}
+	count := uint64(c.count)
+	counters := bytes.Clone(c.counters)
+	c.mu.RUnlock()
+
+	n, err := writeBloomHeader(w, h)
+	if err != nil {
+		return n, err
+	}
+	if err := binary.Write(w, binary.BigEndian, count); err != nil {
+		return n, err
+	}
+	n += 8
+	cn, err := w.Write(counters)
+	return n + int64(cn), err
+}
+
+// ReadFrom 从快照恢复过滤器，替换当前内容
+// 快照的计数器个数、哈希函数个数或容量与当前过滤器不同时返回 ErrBloomSnapshotMismatch
+func (c *CountingBloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	h, n, err := readBloomHeader(r, bloomKindCounting)
+	if err != nil {
+		return n, err
+	}
+	raw, err := readExact(r, 8)
+	if err != nil {
+		return n, err
+	}
+	n += 8
+	count := binary.BigEndian.Uint64(raw)
+	counters, err := readExact(r, h.Bits)
+	if err != nil {
+		return n, err
+	}
+	n += int64(len(counters))
+	if err := checkTrailing(r); err != nil {
+		return n, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == 0 {
+		return n, ErrBloomFilterClosed
+	}
+	if uint64(c.m) != h.Bits || uint64(c.k) != h.HashCount || uint64(c.capacity) != h.Capacity {
+		return n, fmt.Errorf("%w: snapshot m=%d k=%d capacity=%d, filter m=%d k=%d capacity=%d", ErrBloomSnapshotMismatch,
+			h.Bits, h.HashCount, h.Capacity, c.m, c.k, c.capacity)
+	}
+	c.counters = counters
+	c.m = uint(h.Bits)
+	c.k = uint(h.HashCount)
+	c.capacity = uint(h.Capacity)
+	c.count = uint(count)
+	return n, nil
+}
+
+// SaveBloomFilter 将过滤器快照写入文件
+// 先写临时文件再重命名，避免进程中途退出留下损坏的快照
+func SaveBloomFilter(path string, filter io.WriterTo) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if _, err := filter.WriteTo(w); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write bloom filter snapshot: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadBloomFilter 从快照文件恢复过滤器
+// 快照不存在时返回 os.ErrNotExist，文件损坏时返回 ErrBloomSnapshotCorrupt，调用方应回退到全量重建
+func LoadBloomFilter(path string, filter io.ReaderFrom) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	// 限定为文件长度，读取时可以在分配内存前校验长度字段
+	r := &io.LimitedReader{R: bufio.NewReader(file), N: info.Size()}
+	if _, err := filter.ReadFrom(r); err != nil {
+		return fmt.Errorf("failed to read bloom filter snapshot %s: %w", path, err)
+	}
+	return nil
+}
+
+// BloomFilterSnapshotter 定时将布隆过滤器快照写入磁盘
+type BloomFilterSnapshotter struct {
+	filter   io.WriterTo
+	path     string
+	interval time.Duration
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewBloomFilterSnapshotter 创建定时快照器
+func NewBloomFilterSnapshotter(filter io.WriterTo, path string, interval time.Duration) *BloomFilterSnapshotter {
+	return &BloomFilterSnapshotter{
+		filter:   filter,
+		path:     path,
+		interval: interval,
+	}
+}
+
+// Start 启动后台快照goroutine
+func (s *BloomFilterSnapshotter) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := SaveBloomFilter(s.path, s.filter); err != nil {
+					log.Printf("Failed to snapshot bloom filter: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台快照，并写入最后一次快照
+func (s *BloomFilterSnapshotter) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+		s.wg.Wait()
+	}
+	return SaveBloomFilter(s.path, s.filter)
+}
@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type snapshotFilter interface {
+	BloomFilter
+	BloomFilterSnapshot
+}
+
+func snapshotFilters() map[string]func() snapshotFilter {
+	return map[string]func() snapshotFilter{
+		"memory":   func() snapshotFilter { return NewMemoryBloomFilter(100, 0.01).(*MemoryBloomFilter) },
+		"scalable": func() snapshotFilter { return NewScalableBloomFilter(50, 0.01).(*ScalableBloomFilter) },
+		"counting": func() snapshotFilter { return NewCountingBloomFilter(100, 0.01) },
+	}
+}
+
+func TestBloomSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys := make([]string, 200)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	for name, newFilter := range snapshotFilters() {
+		t.Run(name, func(t *testing.T) {
+			src := newFilter()
+			src.BatchAdd(ctx, keys)
+			path := filepath.Join(t.TempDir(), "bloom.snap")
+			if err := SaveBloomFilter(path, src); err != nil {
+				t.Fatal(err)
+			}
+			dst := newFilter()
+			if err := LoadBloomFilter(path, dst); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range keys {
+				if ok, err := dst.Exists(ctx, key); !ok || err != nil {
+					t.Fatalf("%s lost after restore: %v %v", key, ok, err)
+				}
+			}
+			if got, want := dst.Stats(), src.Stats(); got != want {
+				t.Fatalf("stats after restore = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestBloomSnapshotKindMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewMemoryBloomFilter(100, 0.01).(*MemoryBloomFilter).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCountingBloomFilter(100, 0.01).ReadFrom(&buf); !errors.Is(err, ErrBloomSnapshotMismatch) {
+		t.Fatalf("err = %v, want ErrBloomSnapshotMismatch", err)
+	}
+}
+
+func TestBloomSnapshotConfigMismatch(t *testing.T) {
+	ctors := map[string]func(uint, float64) BloomFilterSnapshot{
+		"memory": func(n uint, p float64) BloomFilterSnapshot {
+			return NewMemoryBloomFilter(n, p).(*MemoryBloomFilter)
+		},
+		"scalable": func(n uint, p float64) BloomFilterSnapshot {
+			return NewScalableBloomFilter(n, p).(*ScalableBloomFilter)
+		},
+		"counting": func(n uint, p float64) BloomFilterSnapshot {
+			return NewCountingBloomFilter(n, p)
+		},
+	}
+	for name, newFilter := range ctors {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := newFilter(100, 0.01).WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			// 配置的预期元素数量或误判率变化后，旧快照不能恢复
+			for _, target := range []BloomFilterSnapshot{newFilter(1000, 0.01), newFilter(100, 0.001)} {
+				if _, err := target.ReadFrom(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrBloomSnapshotMismatch) {
+					t.Fatalf("err = %v, want ErrBloomSnapshotMismatch", err)
+				}
+			}
+			if _, err := newFilter(100, 0.01).ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+				t.Fatalf("same config: %v", err)
+			}
+		})
+	}
+}
+
+// snapshotBytes 生成快照后由 corrupt 修改
+func snapshotBytes(t *testing.T, f BloomFilterSnapshot, corrupt func(b []byte) []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return corrupt(buf.Bytes())
+}
+
+// 文件头中各字段的偏移：magic(4) version(1) kind(1) bits(8) hashCount(8) capacity(8)
+const (
+	offsetBits      = 6
+	offsetHashCount = 14
+	offsetMeta      = 30 // 可扩展过滤器的 layers(4) growth(4) fpr(8) tightening(8)
+)
+
+func TestBloomSnapshotCorrupt(t *testing.T) {
+	putUint64 := func(off int, v uint64) func(b []byte) []byte {
+		return func(b []byte) []byte {
+			binary.BigEndian.PutUint64(b[off:], v)
+			return b
+		}
+	}
+	putUint32 := func(off int, v uint32) func(b []byte) []byte {
+		return func(b []byte) []byte {
+			binary.BigEndian.PutUint32(b[off:], v)
+			return b
+		}
+	}
+	memory := func() BloomFilterSnapshot { return NewMemoryBloomFilter(100, 0.01).(*MemoryBloomFilter) }
+	scalable := func() BloomFilterSnapshot { return NewScalableBloomFilter(100, 0.01).(*ScalableBloomFilter) }
+	counting := func() BloomFilterSnapshot { return NewCountingBloomFilter(100, 0.01) }
+
+	tests := []struct {
+		name    string
+		filter  func() BloomFilterSnapshot
+		corrupt func(b []byte) []byte
+	}{
+		{"bad magic", memory, func(b []byte) []byte { return append([]byte("XXXX"), b[4:]...) }},
+		{"empty", memory, func(b []byte) []byte { return nil }},
+		{"truncated header", memory, func(b []byte) []byte { return b[:10] }},
+		{"memory zero bits", memory, putUint64(offsetBits, 0)},
+		{"memory zero hash count", memory, putUint64(offsetHashCount, 0)},
+		{"memory huge bits", memory, putUint64(offsetBits, 1<<40)},
+		{"memory truncated body", memory, func(b []byte) []byte { return b[:len(b)-8] }},
+		{"memory trailing bytes", memory, func(b []byte) []byte { return append(b, 0) }},
+		{"counting zero bits", counting, putUint64(offsetBits, 0)},
+		{"counting zero hash count", counting, putUint64(offsetHashCount, 0)},
+		// 文件头声明了远大于文件长度的计数器个数，不应按该长度分配内存
+		{"counting bits beyond file", counting, putUint64(offsetBits, 1<<31)},
+		{"counting truncated body", counting, func(b []byte) []byte { return b[:len(b)-1] }},
+		{"scalable zero layers", scalable, putUint32(offsetMeta, 0)},
+		{"scalable huge layers", scalable, putUint32(offsetMeta, 1<<30)},
+		{"scalable zero growth", scalable, putUint32(offsetMeta+4, 0)},
+		{"scalable zero layer capacity", scalable, putUint64(offsetMeta+24, 0)},
+		{"scalable layer bits beyond header", scalable, putUint64(offsetBits, 64)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := snapshotBytes(t, tt.filter(), tt.corrupt)
+			path := filepath.Join(t.TempDir(), "bloom.snap")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			target := tt.filter()
+			before := snapshotBytes(t, target, func(b []byte) []byte { return b })
+			if err := LoadBloomFilter(path, target); !errors.Is(err, ErrBloomSnapshotCorrupt) {
+				t.Fatalf("err = %v, want ErrBloomSnapshotCorrupt", err)
+			}
+			// 恢复失败时保留原有内容
+			if after := snapshotBytes(t, target, func(b []byte) []byte { return b }); !bytes.Equal(before, after) {
+				t.Fatal("filter modified by failed restore")
+			}
+		})
+	}
+}
+
+func TestBloomSnapshotCorruptUnknownLength(t *testing.T) {
+	// 无法得知剩余长度时按实际读到的数据报错
+	data := snapshotBytes(t, NewCountingBloomFilter(100, 0.01), func(b []byte) []byte {
+		binary.BigEndian.PutUint64(b[offsetBits:], 1<<31)
+		return b
+	})
+	r := io.MultiReader(bytes.NewReader(data))
+	if _, err := NewCountingBloomFilter(100, 0.01).ReadFrom(r); !errors.Is(err, ErrBloomSnapshotCorrupt) {
+		t.Fatalf("err = %v, want ErrBloomSnapshotCorrupt", err)
+	}
+}
+
+func TestDistributedCacheBloomFilterSnapshot(t *testing.T) {
+	ctx := context.Background()
+	client := NewMemoryL2Client()
+	config := Config{
+		OtterMaxSize:  1000,
+		OtterTTL:      time.Minute,
+		RedisTTL:      time.Minute,
+		PubSubChannel: "cache:sync",
+		InstanceID:    "a",
+		BloomFilter: BloomFilterConfig{
+			Enabled:           true,
+			Type:              BloomFilterScalable,
+			ExpectedElements:  100,
+			FalsePositiveRate: 0.01,
+			SnapshotPath:      filepath.Join(t.TempDir(), "bloom.snap"),
+			SnapshotInterval:  time.Hour,
+		},
+	}
+	dc, err := NewDistributedCacheWithClient(ctx, config, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc.BloomFilter().Add(ctx, "user:1")
+	// 关闭时写入最后一次快照
+	dc.Close()
+	if _, err := dc.BloomFilter().Exists(ctx, "user:1"); !errors.Is(err, ErrBloomFilterClosed) {
+		t.Fatalf("Exists after Close: %v", err)
+	}
+
+	restarted, err := NewDistributedCacheWithClient(ctx, config, NewMemoryL2Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if ok, err := restarted.BloomFilter().Exists(ctx, "user:1"); !ok || err != nil {
+		t.Fatalf("snapshot not restored: %v %v", ok, err)
+	}
+
+	// 快照损坏时从空过滤器启动
+	if err := os.WriteFile(config.BloomFilter.SnapshotPath, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config.InstanceID = "b"
+	fresh, err := NewDistributedCacheWithClient(ctx, config, NewMemoryL2Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if ok, _ := fresh.BloomFilter().Exists(ctx, "user:1"); ok {
+		t.Fatal("corrupt snapshot restored")
+	}
+}
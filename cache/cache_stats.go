@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"zyj.com/golang-study/api"
+)
+
+// cacheMetrics 一级缓存之外的运行统计，一级缓存命中率由 otter 自身统计
+type cacheMetrics struct {
+	secondaryHits      atomic.Uint64
+	secondaryMisses    atomic.Uint64
+	negativeHits       atomic.Uint64
+	loaderCalls        atomic.Uint64
+	loaderErrors       atomic.Uint64
+	loaderLatencyTotal atomic.Int64 // 纳秒
+	loaderLatencyMax   atomic.Int64 // 纳秒
+	syncLagCount       atomic.Uint64
+	syncLagTotal       atomic.Int64 // 纳秒
+	syncLagMax         atomic.Int64 // 纳秒
+}
+
+// observeLoad 记录一次数据源加载
+func (m *cacheMetrics) observeLoad(start time.Time, err error) {
+	elapsed := int64(time.Since(start))
+	m.loaderCalls.Add(1)
+	if err != nil {
+		m.loaderErrors.Add(1)
+	}
+	m.loaderLatencyTotal.Add(elapsed)
+	storeMax(&m.loaderLatencyMax, elapsed)
+}
+
+// observeSyncLag 记录同步消息从发送到被本实例处理的延迟
+func (m *cacheMetrics) observeSyncLag(lag time.Duration) {
+	if lag < 0 {
+		// 实例间时钟不同步时可能为负数
+		lag = 0
+	}
+	m.syncLagCount.Add(1)
+	m.syncLagTotal.Add(int64(lag))
+	storeMax(&m.syncLagMax, int64(lag))
+}
+
+func storeMax(target *atomic.Int64, value int64) {
+	for {
+		current := target.Load()
+		if value <= current || target.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// 缓存统计信息
+type CacheStats struct {
+	InstanceID         string        `json:"instanceID"`
+	PrimaryHits        uint64        `json:"primaryHits"`
+	PrimaryMisses      uint64        `json:"primaryMisses"`
+	PrimaryEvictions   uint64        `json:"primaryEvictions"`
+	PrimarySize        int           `json:"primarySize"`
+	SecondaryHits      uint64        `json:"secondaryHits"`
+	SecondaryMisses    uint64        `json:"secondaryMisses"`
+	NegativeHits       uint64        `json:"negativeHits"` // 命中空值缓存次数，使用方主动写入的空字符串同样计入
+	LoaderCalls        uint64        `json:"loaderCalls"`
+	LoaderErrors       uint64        `json:"loaderErrors"`
+	LoaderLatencyTotal time.Duration `json:"loaderLatencyTotal"`
+	LoaderLatencyAvg   time.Duration `json:"loaderLatencyAvg"`
+	LoaderLatencyMax   time.Duration `json:"loaderLatencyMax"`
+	MessagesSent       uint64        `json:"syncMessagesSent"`
+	MessagesRecvd      uint64        `json:"syncMessagesReceived"`
+	SyncLagAvg         time.Duration `json:"syncLagAvg"`
+	SyncLagMax         time.Duration `json:"syncLagMax"`
+	HotKeys            []HotKey      `json:"hotKeys,omitempty"`
+	PinnedKeys         int           `json:"pinnedKeys"`
+	PinnedHits         uint64        `json:"pinnedHits"`
+	PinnedMisses       uint64        `json:"pinnedMisses"`
+}
+
+// GetStats 返回缓存统计信息，Close 之后一级缓存相关统计为零值
+func (dc *DistributedCache) GetStats() *CacheStats {
+	loaderCalls := dc.metrics.loaderCalls.Load()
+	loaderLatencyTotal := time.Duration(dc.metrics.loaderLatencyTotal.Load())
+	syncLagCount := dc.metrics.syncLagCount.Load()
+
+	stats := &CacheStats{
+		InstanceID:         dc.config.InstanceID,
+		SecondaryHits:      dc.metrics.secondaryHits.Load(),
+		SecondaryMisses:    dc.metrics.secondaryMisses.Load(),
+		NegativeHits:       dc.metrics.negativeHits.Load(),
+		LoaderCalls:        loaderCalls,
+		LoaderErrors:       dc.metrics.loaderErrors.Load(),
+		LoaderLatencyTotal: loaderLatencyTotal,
+		LoaderLatencyMax:   time.Duration(dc.metrics.loaderLatencyMax.Load()),
+		MessagesSent:       dc.MsgSendCount.Load(),
+		MessagesRecvd:      dc.MsgRecvdCount.Load(),
+		SyncLagMax:         time.Duration(dc.metrics.syncLagMax.Load()),
+	}
+	if primaryCache := dc.primaryCache; primaryCache != nil {
+		primaryStats := primaryCache.Stats()
+		stats.PrimaryHits = primaryStats.Hits
+		stats.PrimaryMisses = primaryStats.Misses
+		stats.PrimaryEvictions = primaryStats.Evictions
+		stats.PrimarySize = primaryCache.EstimatedSize()
+	}
+	if dc.hotKeys != nil {
+		stats.HotKeys = dc.hotKeys.hotKeys()
+		if dc.hotKeys.pinned != nil {
+			pinnedStats := dc.hotKeys.pinned.Stats()
+			stats.PinnedKeys = dc.hotKeys.pinned.EstimatedSize()
+			stats.PinnedHits = pinnedStats.Hits
+			stats.PinnedMisses = pinnedStats.Misses
+		}
+	}
+	if loaderCalls > 0 {
+		stats.LoaderLatencyAvg = loaderLatencyTotal / time.Duration(loaderCalls)
+	}
+	if syncLagCount > 0 {
+		stats.SyncLagAvg = time.Duration(dc.metrics.syncLagTotal.Load()) / time.Duration(syncLagCount)
+	}
+	return stats
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出统计信息
+func (s *CacheStats) WritePrometheus(w io.Writer) error {
+	label := fmt.Sprintf("{instance=%q}", s.InstanceID)
+	metrics := []struct {
+		name  string
+		kind  string
+		help  string
+		value float64
+	}{
+		{"cache_primary_hits_total", "counter", "L1 cache hits.", float64(s.PrimaryHits)},
+		{"cache_primary_misses_total", "counter", "L1 cache misses.", float64(s.PrimaryMisses)},
+		{"cache_primary_evictions_total", "counter", "L1 cache size-based evictions.", float64(s.PrimaryEvictions)},
+		{"cache_primary_size", "gauge", "Estimated number of L1 cache entries.", float64(s.PrimarySize)},
+		{"cache_secondary_hits_total", "counter", "L2 cache hits.", float64(s.SecondaryHits)},
+		{"cache_secondary_misses_total", "counter", "L2 cache misses.", float64(s.SecondaryMisses)},
+		{"cache_negative_hits_total", "counter", "Hits on cached empty values.", float64(s.NegativeHits)},
+		{"cache_loader_calls_total", "counter", "Data source loader calls.", float64(s.LoaderCalls)},
+		{"cache_loader_errors_total", "counter", "Data source loader errors.", float64(s.LoaderErrors)},
+		{"cache_loader_latency_seconds_total", "counter", "Total time spent in data source loaders.", s.LoaderLatencyTotal.Seconds()},
+		{"cache_loader_latency_seconds_max", "gauge", "Max data source loader latency.", s.LoaderLatencyMax.Seconds()},
+		{"cache_sync_messages_sent_total", "counter", "Sync messages published.", float64(s.MessagesSent)},
+		{"cache_sync_messages_received_total", "counter", "Sync messages received.", float64(s.MessagesRecvd)},
+		{"cache_sync_lag_seconds_avg", "gauge", "Average sync message lag.", s.SyncLagAvg.Seconds()},
+		{"cache_sync_lag_seconds_max", "gauge", "Max sync message lag.", s.SyncLagMax.Seconds()},
+		{"cache_hot_keys", "gauge", "Number of detected hot keys.", float64(len(s.HotKeys))},
+		{"cache_pinned_keys", "gauge", "Number of hot keys pinned locally.", float64(s.PinnedKeys)},
+		{"cache_pinned_hits_total", "counter", "Pinned hot key hits.", float64(s.PinnedHits)},
+		{"cache_pinned_misses_total", "counter", "Pinned hot key misses.", float64(s.PinnedMisses)},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n",
+			m.name, m.help, m.name, m.kind, m.name, label, m.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StatsHandler 以 JSON 返回缓存统计信息
+func (dc *DistributedCache) StatsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, api.NewResult(dc.GetStats(), nil))
+	}
+}
+
+// PrometheusHandler 以 Prometheus 文本格式返回缓存统计信息
+func (dc *DistributedCache) PrometheusHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		if err := dc.GetStats().WritePrometheus(ctx.Writer); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
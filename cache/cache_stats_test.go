@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	stats := &CacheStats{InstanceID: "a", PrimaryHits: 3, NegativeHits: 1}
+	var sb strings.Builder
+	if err := stats.WritePrometheus(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# HELP cache_primary_hits_total L1 cache hits.\n",
+		"# TYPE cache_primary_hits_total counter\n",
+		`cache_primary_hits_total{instance="a"} 3` + "\n",
+		`cache_negative_hits_total{instance="a"} 1` + "\n",
+		"# TYPE cache_primary_size gauge\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func serveStats(handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/stats", handler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	return w
+}
+
+func TestStatsHandlers(t *testing.T) {
+	dc := newTestCache(t, NewMemoryL2Client(), "a")
+	dc.Set("k", "v")
+	cachedValue(dc, "k")
+
+	w := serveStats(dc.StatsHandler())
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	var body struct {
+		Data CacheStats `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.InstanceID != "a" || body.Data.PrimaryHits != 1 {
+		t.Fatalf("stats: %+v", body.Data)
+	}
+
+	w = serveStats(dc.PrometheusHandler())
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("status %d content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `cache_primary_hits_total{instance="a"} 1`) {
+		t.Fatalf("body:\n%s", w.Body.String())
+	}
+}
+
+func TestStatsHandlersAfterClose(t *testing.T) {
+	dc, err := NewDistributedCacheWithClient(t.Context(), Config{OtterMaxSize: 1000, InstanceID: "a"}, NewMemoryL2Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc.Close()
+	for _, handler := range []gin.HandlerFunc{dc.StatsHandler(), dc.PrometheusHandler()} {
+		if w := serveStats(handler); w.Code != http.StatusOK {
+			t.Fatalf("status %d", w.Code)
+		}
+	}
+}
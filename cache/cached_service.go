@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"zyj.com/golang-study/pkg/tserror"
+	"zyj.com/golang-study/xorm/base"
+	"zyj.com/golang-study/xorm/base/database"
+)
+
+// CachedService 基于 DistributedCache 的 cache-aside 装饰器
+// GetByID/ListByIds 优先读缓存，数据库中不存在的ID缓存空值并在 NegativeTTL 后过期，其他错误不缓存；
+// Create/BatchCreate/UpdateById/BatchUpdateByIds/DeleteById 成功后失效缓存（清除新ID此前缓存的空值）；
+// 处于事务中时，读取绕过缓存直接查数据库（避免未提交的数据进入缓存），失效推迟到事务提交之后，回滚则不失效
+type CachedService[T any, K comparable] struct {
+	*base.BaseService[T, K]
+	cache *DistributedCache
+}
+
+// NewCachedService 创建带缓存的Service
+func NewCachedService[T any, K comparable](service *base.BaseService[T, K], cache *DistributedCache) *CachedService[T, K] {
+	return &CachedService[T, K]{
+		BaseService: service,
+		cache:       cache,
+	}
+}
+
+// cacheKey 缓存键：表名:主键列名:主键值，如 users:id:42
+func (cs *CachedService[T, K]) cacheKey(id any) (string, error) {
+	table, err := database.GetTableName[T]()
+	if err != nil {
+		return "", err
+	}
+	pk, err := database.GetPrimaryKey[T]()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s:%v", table, pk, id), nil
+}
+
+// GetByID 根据ID获取实体，未命中时从数据库加载并回填缓存
+func (cs *CachedService[T, K]) GetByID(id K) (*T, error) {
+	if database.InTransaction() {
+		return cs.BaseService.GetByID(id)
+	}
+	key, err := cs.cacheKey(id)
+	if err != nil {
+		return nil, err
+	}
+	value, err := cs.cache.get(key, func(ctx context.Context, key string) (string, error) {
+		entity, err := cs.BaseService.GetByID(id)
+		if errors.Is(err, tserror.ErrEntityNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(entity)
+		return string(data), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, tserror.TranslateDBError(tserror.ErrEntityNotFound)
+	}
+	var entity T
+	if err := json.Unmarshal([]byte(value), &entity); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached entity %s: %v", key, err)
+	}
+	return &entity, nil
+}
+
+// ListByIds 根据ID列表获取实体，只从数据库加载缓存未命中的部分，不存在的ID缓存空值，查询失败时不缓存
+func (cs *CachedService[T, K]) ListByIds(ids []K) ([]T, error) {
+	if database.InTransaction() {
+		return cs.BaseService.ListByIds(ids)
+	}
+	keys := make([]string, len(ids))
+	idOfKey := make(map[string]K, len(ids))
+	for i, id := range ids {
+		key, err := cs.cacheKey(id)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		idOfKey[key] = id
+	}
+
+	values, err := cs.cache.MGetWithLoader(keys, func(ctx context.Context, missingKeys []string) ([]string, error) {
+		missingIds := make([]K, len(missingKeys))
+		for i, key := range missingKeys {
+			missingIds[i] = idOfKey[key]
+		}
+		entities, err := cs.BaseService.ListByIds(missingIds)
+		if err != nil {
+			return nil, err
+		}
+		loaded := make(map[string]string, len(entities))
+		for i := range entities {
+			id, err := database.GetPrimaryKeyValue(&entities[i])
+			if err != nil {
+				return nil, err
+			}
+			key, err := cs.cacheKey(id)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(&entities[i])
+			if err != nil {
+				return nil, err
+			}
+			loaded[key] = string(data)
+		}
+		result := make([]string, len(missingKeys))
+		for i, key := range missingKeys {
+			result[i] = loaded[key]
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]T, 0, len(ids))
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok || value == "" {
+			continue
+		}
+		var entity T
+		if err := json.Unmarshal([]byte(value), &entity); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cached entity %s: %v", key, err)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// Create 创建实体并失效新ID的缓存
+func (cs *CachedService[T, K]) Create(entity *T) error {
+	err := cs.BaseService.Create(entity)
+	if err == nil {
+		cs.invalidateEntities(entity)
+	}
+	return err
+}
+
+// BatchCreate 批量创建实体并失效新ID的缓存
+func (cs *CachedService[T, K]) BatchCreate(entities *[]T) error {
+	err := cs.BaseService.BatchCreate(entities)
+	if err == nil {
+		list := make([]*T, len(*entities))
+		for i := range *entities {
+			list[i] = &(*entities)[i]
+		}
+		cs.invalidateEntities(list...)
+	}
+	return err
+}
+
+// UpdateById 更新实体并失效缓存
+func (cs *CachedService[T, K]) UpdateById(id K, entity *T) (int64, error) {
+	count, err := cs.BaseService.UpdateById(id, entity)
+	if err == nil {
+		cs.invalidate(id)
+	}
+	return count, err
+}
+
+// BatchUpdateByIds 批量更新实体并失效缓存
+func (cs *CachedService[T, K]) BatchUpdateByIds(ids []K, entity *T) (int64, error) {
+	count, err := cs.BaseService.BatchUpdateByIds(ids, entity)
+	if err == nil {
+		keys := make([]any, len(ids))
+		for i, id := range ids {
+			keys[i] = id
+		}
+		cs.invalidate(keys...)
+	}
+	return count, err
+}
+
+// DeleteById 删除实体并失效缓存
+func (cs *CachedService[T, K]) DeleteById(id int64, entity *T) error {
+	err := cs.BaseService.DeleteById(id, entity)
+	if err == nil {
+		cs.invalidate(id)
+	}
+	return err
+}
+
+// invalidate 失效实体的缓存，事务中推迟到提交之后
+func (cs *CachedService[T, K]) invalidate(ids ...any) {
+	database.AfterCommit(func() {
+		for _, id := range ids {
+			cs.deleteKey(id)
+		}
+	})
+}
+
+// invalidateEntities 按实体主键失效缓存，主键未回填（零值）的实体跳过
+func (cs *CachedService[T, K]) invalidateEntities(entities ...*T) {
+	ids := make([]any, 0, len(entities))
+	for _, entity := range entities {
+		id, err := database.GetPrimaryKeyValue(entity)
+		if err != nil {
+			log.Printf("Failed to get primary key: %v", err)
+			continue
+		}
+		if id != nil && !reflect.ValueOf(id).IsZero() {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		cs.invalidate(ids...)
+	}
+}
+
+func (cs *CachedService[T, K]) deleteKey(id any) {
+	key, err := cs.cacheKey(id)
+	if err != nil {
+		log.Printf("Failed to build cache key: %v", err)
+		return
+	}
+	if err := cs.cache.Delete(key); err != nil {
+		log.Printf("Failed to invalidate cache %s: %v", key, err)
+	}
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"zyj.com/golang-study/pkg/tserror"
+	"zyj.com/golang-study/xorm/base"
+	"zyj.com/golang-study/xorm/base/database"
+)
+
+type cachedUser struct {
+	Id   int64  `xorm:"pk autoincr 'id'" json:"id"`
+	Name string `xorm:"'name'" json:"name"`
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "cache")
+	if err != nil {
+		panic(err)
+	}
+	if err := database.Init("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	database.CloseEngine()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestCachedService 重建 cached_user 表并返回带缓存的Service
+func newTestCachedService(t *testing.T) *CachedService[cachedUser, int64] {
+	t.Helper()
+	session := database.GetDBSession()
+	defer database.ReturnSession(session)
+	if err := session.DropTable(new(cachedUser)); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Sync(new(cachedUser)); err != nil {
+		t.Fatal(err)
+	}
+	return NewCachedService(&base.BaseService[cachedUser, int64]{}, newTestCache(t, NewMemoryL2Client(), "a"))
+}
+
+// renameInDB 绕过缓存直接修改数据库
+func renameInDB(t *testing.T, id int64, name string) {
+	t.Helper()
+	session := database.GetDBSession()
+	defer database.ReturnSession(session)
+	if _, err := session.Exec("UPDATE cached_user SET name = ? WHERE id = ?", name, id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustGetName(t *testing.T, cs *CachedService[cachedUser, int64], id int64) string {
+	t.Helper()
+	user, err := cs.GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.Name
+}
+
+func TestCachedServiceReadThrough(t *testing.T) {
+	cs := newTestCachedService(t)
+	user := &cachedUser{Name: "alice"}
+	if err := cs.Create(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := mustGetName(t, cs, user.Id); name != "alice" {
+		t.Fatalf("got %q", name)
+	}
+	// 第二次读取命中缓存，看不到绕过缓存的修改
+	renameInDB(t, user.Id, "bob")
+	if name := mustGetName(t, cs, user.Id); name != "alice" {
+		t.Fatalf("expected cached value, got %q", name)
+	}
+	users, err := cs.ListByIds([]int64{user.Id})
+	if err != nil || len(users) != 1 || users[0].Name != "alice" {
+		t.Fatalf("list: %v %v", users, err)
+	}
+}
+
+func TestCachedServiceInvalidatesOnUpdateAndDelete(t *testing.T) {
+	cs := newTestCachedService(t)
+	user := &cachedUser{Name: "alice"}
+	if err := cs.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	mustGetName(t, cs, user.Id)
+
+	if _, err := cs.UpdateById(user.Id, &cachedUser{Name: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if name := mustGetName(t, cs, user.Id); name != "bob" {
+		t.Fatalf("after update: got %q", name)
+	}
+
+	if _, err := cs.BatchUpdateByIds([]int64{user.Id}, &cachedUser{Name: "carol"}); err != nil {
+		t.Fatal(err)
+	}
+	if name := mustGetName(t, cs, user.Id); name != "carol" {
+		t.Fatalf("after batch update: got %q", name)
+	}
+
+	if err := cs.DeleteById(user.Id, &cachedUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.GetByID(user.Id); !errors.Is(err, tserror.ErrEntityNotFound) {
+		t.Fatalf("after delete: got %v", err)
+	}
+}
+
+func TestCachedServiceNegativeCache(t *testing.T) {
+	cs := newTestCachedService(t)
+	key, _ := cs.cacheKey(int64(1))
+
+	// 不存在的ID缓存空值，过期时间为 NegativeTTL
+	if _, err := cs.GetByID(1); !errors.Is(err, tserror.ErrEntityNotFound) {
+		t.Fatalf("got %v", err)
+	}
+	entry, ok := cs.cache.primaryCache.GetEntryQuietly(key)
+	if !ok || entry.Value != "" {
+		t.Fatalf("not found should be cached as empty value: %+v %v", entry, ok)
+	}
+	if entry.ExpiresAfter() > defaultNegativeTTL {
+		t.Fatalf("negative entry expires after %v", entry.ExpiresAfter())
+	}
+	client := cs.cache.secondaryCache.(*MemoryL2Client)
+	client.mu.Lock()
+	ttl := time.Until(client.data[key].expireAt)
+	client.mu.Unlock()
+	if ttl <= 0 || ttl > defaultNegativeTTL {
+		t.Fatalf("negative L2 entry ttl %v", ttl)
+	}
+
+	// 创建后失效此前缓存的空值
+	user := &cachedUser{Name: "alice"}
+	if err := cs.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != 1 {
+		t.Fatalf("unexpected id %d", user.Id)
+	}
+	if name := mustGetName(t, cs, 1); name != "alice" {
+		t.Fatalf("got %q", name)
+	}
+
+	// 批量创建同样失效空值
+	users, err := cs.ListByIds([]int64{2, 3})
+	if err != nil || len(users) != 0 {
+		t.Fatalf("list: %v %v", users, err)
+	}
+	batch := []cachedUser{{Id: 2, Name: "bob"}, {Id: 3, Name: "carol"}}
+	if err := cs.BatchCreate(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if users, err := cs.ListByIds([]int64{2, 3}); err != nil || len(users) != 2 {
+		t.Fatalf("list after batch create: %v %v", users, err)
+	}
+}
+
+func TestCachedServiceDoesNotCacheErrors(t *testing.T) {
+	cs := newTestCachedService(t)
+	key, _ := cs.cacheKey(int64(0))
+
+	// 参数校验失败等非“不存在”错误原样返回且不缓存
+	if _, err := cs.GetByID(0); err == nil || errors.Is(err, tserror.ErrEntityNotFound) {
+		t.Fatalf("got %v", err)
+	}
+	if _, ok := cs.cache.primaryCache.GetIfPresent(key); ok {
+		t.Fatal("error result should not be cached in L1")
+	}
+	if _, err := cs.cache.secondaryCache.Get(context.Background(), key); err != ErrL2Nil {
+		t.Fatalf("error result should not be cached in L2: %v", err)
+	}
+}
+
+func TestCachedServiceInTransaction(t *testing.T) {
+	cs := newTestCachedService(t)
+	user := &cachedUser{Name: "alice"}
+	if err := cs.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	mustGetName(t, cs, user.Id)
+
+	errRollback := errors.New("rollback")
+	err := cs.ExecuteTx(func() error {
+		if _, err := cs.UpdateById(user.Id, &cachedUser{Name: "bob"}); err != nil {
+			return err
+		}
+		// 事务中读取绕过缓存，能看到未提交的修改
+		if name := mustGetName(t, cs, user.Id); name != "bob" {
+			t.Fatalf("in transaction: got %q", name)
+		}
+		// 失效推迟到提交之后
+		if value := cachedValue(cs.cache, mustKey(t, cs, user.Id)); value == "" {
+			t.Fatal("cache invalidated before commit")
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("got %v", err)
+	}
+	// 回滚后缓存未失效，仍是原值
+	if name := mustGetName(t, cs, user.Id); name != "alice" {
+		t.Fatalf("after rollback: got %q", name)
+	}
+
+	err = cs.ExecuteTx(func() error {
+		_, err := cs.UpdateById(user.Id, &cachedUser{Name: "carol"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := mustGetName(t, cs, user.Id); name != "carol" {
+		t.Fatalf("after commit: got %q", name)
+	}
+}
+
+func mustKey(t *testing.T, cs *CachedService[cachedUser, int64], id int64) string {
+	t.Helper()
+	key, err := cs.cacheKey(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// CountingBloomFilter 计数布隆过滤器，每个位置使用8位计数器代替单个bit，支持删除元素
+type CountingBloomFilter struct {
+	mu       sync.RWMutex
+	counters []uint8
+	m        uint // 计数器个数
+	k        uint // 哈希函数个数
+	capacity uint
+	count    uint
+}
+
+// NewCountingBloomFilter 创建计数布隆过滤器
+// expectedElements: 预期元素数量
+// falsePositiveRate: 误判率
+func NewCountingBloomFilter(expectedElements uint, falsePositiveRate float64) *CountingBloomFilter {
+	m, k := bloom.EstimateParameters(expectedElements, falsePositiveRate)
+	return &CountingBloomFilter{
+		counters: make([]uint8, m),
+		m:        m,
+		k:        k,
+		capacity: expectedElements,
+	}
+}
+
+// locations 计算元素对应的计数器下标，与 bloom 库使用相同的哈希方案
+func (c *CountingBloomFilter) locations(key string) []uint64 {
+	locs := bloom.Locations([]byte(key), c.k)
+	for i := range locs {
+		locs[i] %= uint64(c.m)
+	}
+	return locs
+}
+
+// add 添加单个元素，调用方需持有写锁
+func (c *CountingBloomFilter) add(key string) {
+	for _, loc := range c.locations(key) {
+		// 计数器饱和后不再增加，也不会再被减少，保证不会出现漏判
+		if c.counters[loc] < math.MaxUint8 {
+			c.counters[loc]++
+		}
+	}
+	c.count++
+}
+
+func (c *CountingBloomFilter) Add(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == 0 {
+		return ErrBloomFilterClosed
+	}
+	c.add(key)
+	return nil
+}
+
+func (c *CountingBloomFilter) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.m == 0 {
+		return false, ErrBloomFilterClosed
+	}
+	for _, loc := range c.locations(key) {
+		if c.counters[loc] == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (c *CountingBloomFilter) BatchAdd(ctx context.Context, keys []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == 0 {
+		return ErrBloomFilterClosed
+	}
+	for _, key := range keys {
+		c.add(key)
+	}
+	return nil
+}
+
+// Remove 删除元素
+// 只能删除确实添加过的元素，删除未添加的元素可能导致其他元素漏判；
+// 元素确定不存在时不做任何处理
+func (c *CountingBloomFilter) Remove(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == 0 {
+		return ErrBloomFilterClosed
+	}
+	locs := c.locations(key)
+	for _, loc := range locs {
+		if c.counters[loc] == 0 {
+			return nil
+		}
+	}
+	for _, loc := range locs {
+		if c.counters[loc] < math.MaxUint8 {
+			c.counters[loc]--
+		}
+	}
+	if c.count > 0 {
+		c.count--
+	}
+	return nil
+}
+
+func (c *CountingBloomFilter) Stats() BloomFilterStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.m == 0 {
+		return BloomFilterStats{}
+	}
+	var nonZero uint
+	for _, counter := range c.counters {
+		if counter > 0 {
+			nonZero++
+		}
+	}
+	fill := float64(nonZero) / float64(c.m)
+	return BloomFilterStats{
+		Capacity:     c.capacity,
+		Count:        c.count,
+		Bits:         c.m,
+		HashCount:    c.k,
+		Layers:       1,
+		FillRatio:    fill,
+		EstimatedFPR: estimateFPR(fill, c.k),
+	}
+}
+
+func (c *CountingBloomFilter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters = nil
+	c.m = 0
+	return nil
+}
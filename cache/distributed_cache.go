@@ -3,23 +3,33 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/maypok86/otter/v2/stats"
 	"log"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/maypok86/otter/v2"
+	"zyj.com/golang-study/gopool"
 )
 
+// 异步刷新协程池默认容量
+const defaultRefreshPoolSize = 64
+
+// 空值缓存默认过期时间
+const defaultNegativeTTL = time.Minute
+
 // 缓存操作类型
 type OperationType string
 
 const (
-	OperationDelete OperationType = "delete"
-	OperationSet    OperationType = "set"
+	OperationDelete           OperationType = "delete"
+	OperationSet              OperationType = "set"
+	OperationInvalidateKeys   OperationType = "invalidate_keys"   // 按标签失效，Keys 为标签下的全部键
+	OperationInvalidatePrefix OperationType = "invalidate_prefix" // 按前缀失效，Key 为前缀
 )
 
 // 缓存同步消息结构
@@ -27,6 +37,7 @@ type SyncMessage struct {
 	Operation  OperationType `json:"operation"`
 	Key        string        `json:"key"`
 	Value      string        `json:"value,omitempty"` // 仅set操作需要
+	Keys       []string      `json:"keys,omitempty"`  // 仅批量失效操作需要
 	InstanceID string        `json:"instance_id"`     // 消息来源实例ID
 	Timestamp  int64         `json:"timestamp"`       // 消息时间戳
 }
@@ -34,14 +45,41 @@ type SyncMessage struct {
 // 二级缓存配置
 type Config struct {
 	// Otter 一级缓存配置
-	OtterMaxSize int           `json:"otterMaxSize"`
-	OtterTTL     time.Duration `json:"otterTTL"`
+	OtterMaxSize int `json:"otterMaxSize"`
+	// 硬过期：默认按最后一次读写计时，超过该时间未被访问的条目被移除，下次读取同步加载
+	OtterTTL time.Duration `json:"otterTTL"`
+	// OtterTTL 改为按写入（或刷新）计时，频繁读取不会延长过期时间
+	OtterExpireAfterWrite bool `json:"otterExpireAfterWrite"`
+	// 软过期：写入（或刷新）后超过该时间，读取时先返回旧值，同时由协程池中的一个goroutine
+	// 从二级缓存/加载器异步刷新。应小于 OtterTTL，0 表示不启用刷新
+	OtterRefreshAfter time.Duration `json:"otterRefreshAfter"`
+	RefreshPoolSize   int32         `json:"refreshPoolSize"` // 异步刷新协程池容量，0 使用默认值
 
 	// Redis 二级缓存配置
-	RedisAddr     string        `json:"redisAddr"`
-	RedisPassword string        `json:"redisPassword"`
-	RedisDB       int           `json:"redisDB"`
-	RedisTTL      time.Duration `json:"redisTTL"`
+	RedisMode             string        `json:"redisMode"`  // single/cluster/sentinel，为空时自动推断
+	RedisAddr             string        `json:"redisAddr"`  // 单节点地址，RedisAddrs 为空时使用
+	RedisAddrs            []string      `json:"redisAddrs"` // 集群种子节点或哨兵节点地址
+	RedisMasterName       string        `json:"redisMasterName"`
+	RedisPassword         string        `json:"redisPassword"`
+	RedisSentinelPassword string        `json:"redisSentinelPassword"`
+	RedisDB               int           `json:"redisDB"` // 集群模式下忽略
+	RedisPoolSize         int           `json:"redisPoolSize"`
+	RedisTTL              time.Duration `json:"redisTTL"`
+	// 过期时间随机抖动比例，如 0.1 表示实际过期时间在 [TTL, 1.1*TTL) 之间随机
+	RedisTTLJitter float64 `json:"redisTTLJitter"`
+	// 按键前缀覆盖 RedisTTL，最长前缀优先
+	TTLRules []TTLRule `json:"ttlRules"`
+	// 空值（数据源中不存在的键）在一二级缓存中的过期时间，0 使用默认值
+	NegativeTTL time.Duration `json:"negativeTTL"`
+
+	// 热点键探测配置
+	HotKey HotKeyConfig `json:"hotKey"`
+
+	// 写回模式配置
+	WriteBehind WriteBehindConfig `json:"writeBehind"`
+
+	// 布隆过滤器配置
+	BloomFilter BloomFilterConfig `json:"bloomFilter"`
 
 	// Pub/Sub 配置
 	PubSubChannel string `json:"pubSubChannel"`
@@ -51,47 +89,125 @@ type Config struct {
 // 分布式二级缓存主结构
 type DistributedCache struct {
 	primaryCache   *otter.Cache[string, string] // 一级缓存 (Otter)
-	secondaryCache *redis.Client                // 二级缓存 (Redis)
-	pubSub         *redis.PubSub                // Redis Pub/Sub
+	secondaryCache L2Client                     // 二级缓存 (Redis 单节点/集群/哨兵)
+	pubSub         L2Subscription               // 同步消息订阅
+	refreshPool    *refreshExecutor             // 一级缓存异步刷新协程池
 	config         Config
+	ttlRules       []TTLRule // 按前缀长度降序排列的过期规则
 	cacheCtx       context.Context
 	cancel         context.CancelFunc
+	listenDone     chan struct{}   // 消息监听goroutine退出信号
 	syncMutex      sync.RWMutex    // 同步操作锁
 	localOnlyKeys  map[string]bool // 仅本地操作标记
 	MsgSendCount   atomic.Uint64
 	MsgRecvdCount  atomic.Uint64
+	metrics        cacheMetrics            // 二级缓存、加载器、同步消息统计
+	hotKeys        *hotKeyDetector         // 热点键探测器，未启用时为 nil
+	writeBehind    *writeBehindQueue       // 写回队列，未启用时为 nil
+	bloomFilter    BloomFilter             // 布隆过滤器，未启用时为 nil
+	bloomSnapshot  *BloomFilterSnapshotter // 布隆过滤器定时快照，未配置快照路径时为 nil
 }
 
 // 创建分布式缓存实例
 func NewDistributedCache(ctx context.Context, config Config) (*DistributedCache, error) {
+	// 初始化二级缓存 (Redis)
+	redisClient, err := newRedisClient(&config)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := NewDistributedCacheWithClient(ctx, config, NewRedisL2Client(redisClient))
+	if err != nil {
+		redisClient.Close()
+		return nil, err
+	}
+	return cache, nil
+}
+
+// NewDistributedCacheWithClient 使用指定的二级缓存客户端创建分布式缓存实例
+// 测试时可传入 MemoryL2Client，多个实例共享同一个客户端即可模拟多实例部署
+func NewDistributedCacheWithClient(ctx context.Context, config Config, secondaryCache L2Client) (*DistributedCache, error) {
 	// 创建带取消的上下文
 	cacheCtx, cancel := context.WithCancel(ctx)
 
-	// 初始化一级缓存 (Otter)
-	primaryCache := otter.Must(&otter.Options[string, string]{
-		MaximumSize:     config.OtterMaxSize,
-		InitialCapacity: 100,
-		Weigher: func(key string, value string) uint32 {
-			return uint32(len(key) + len(value))
-		},
-		ExpiryCalculator:  otter.ExpiryAccessing[string, string](config.OtterTTL),
-		RefreshCalculator: otter.RefreshWriting[string, string](config.OtterTTL),
-		StatsRecorder:     stats.NewCounter(),
-	})
+	// 异步刷新协程池，刷新任务不占用请求goroutine
+	poolSize := config.RefreshPoolSize
+	if poolSize <= 0 {
+		poolSize = defaultRefreshPoolSize
+	}
+	refreshPool := &refreshExecutor{pool: gopool.NewPool("cache.refresh."+config.InstanceID, poolSize, gopool.NewConfig())}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = defaultNegativeTTL
+	}
 
-	// 初始化二级缓存 (Redis)
-	secondaryCache := redis.NewClient(&redis.Options{
-		Addr:     config.RedisAddr,
-		Password: config.RedisPassword,
-		DB:       config.RedisDB,
-	})
+	// 初始化一级缓存 (Otter)，过期时间按键前缀规则计算，空值按 NegativeTTL
+	ttlRules := sortTTLRules(config.TTLRules)
+	expiresAfter := func(entry otter.Entry[string, string]) time.Duration {
+		ttl := l1TTL(config.OtterTTL, ttlRules, entry.Key)
+		if entry.Value == "" && (ttl <= 0 || config.NegativeTTL < ttl) {
+			return config.NegativeTTL
+		}
+		return ttl
+	}
+	expiry := otter.ExpiryAccessingFunc(expiresAfter)
+	if config.OtterExpireAfterWrite {
+		expiry = otter.ExpiryWritingFunc(expiresAfter)
+	}
+	options := &otter.Options[string, string]{
+		MaximumSize:      config.OtterMaxSize,
+		InitialCapacity:  100,
+		ExpiryCalculator: expiry,
+		StatsRecorder:    stats.NewCounter(),
+		Executor:         refreshPool.Go,
+	}
+	if config.OtterRefreshAfter > 0 {
+		options.RefreshCalculator = otter.RefreshWriting[string, string](config.OtterRefreshAfter)
+	}
+	primaryCache := otter.Must(options)
 
 	// 测试Redis连接
-	if err := secondaryCache.Ping(cacheCtx).Err(); err != nil {
+	if err := secondaryCache.Ping(cacheCtx); err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to connect to redis: %v", err)
 	}
 
+	// 初始化布隆过滤器，从上次的快照恢复
+	var bloomFilter BloomFilter
+	var bloomSnapshot *BloomFilterSnapshotter
+	if config.BloomFilter.Enabled {
+		var err error
+		if bloomFilter, err = newBloomFilter(config.BloomFilter); err != nil {
+			cancel()
+			return nil, err
+		}
+		if path := config.BloomFilter.SnapshotPath; path != "" {
+			snapshot, ok := bloomFilter.(BloomFilterSnapshot)
+			if !ok {
+				bloomFilter.Close()
+				cancel()
+				return nil, fmt.Errorf("bloom filter type %q does not support snapshots", config.BloomFilter.Type)
+			}
+			// 快照不存在、已损坏或与配置的容量/误判率不一致时从空过滤器开始，由使用方重新预热
+			if err := LoadBloomFilter(path, snapshot); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Failed to restore bloom filter snapshot, starting empty: %v", err)
+			}
+			interval := config.BloomFilter.SnapshotInterval
+			if interval <= 0 {
+				interval = defaultBloomSnapshotInterval
+			}
+			bloomSnapshot = NewBloomFilterSnapshotter(snapshot, path, interval)
+		}
+	}
+
+	// 初始化写回队列，回放上次退出前未写入的数据
+	var writeBehind *writeBehindQueue
+	if config.WriteBehind.Enabled {
+		var err error
+		if writeBehind, err = newWriteBehindQueue(config.WriteBehind); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to init write-behind queue: %v", err)
+		}
+	}
+
 	// 创建Pub/Sub订阅
 	pubSub := secondaryCache.Subscribe(cacheCtx, config.PubSubChannel)
 
@@ -99,18 +215,45 @@ func NewDistributedCache(ctx context.Context, config Config) (*DistributedCache,
 		primaryCache:   primaryCache,
 		secondaryCache: secondaryCache,
 		pubSub:         pubSub,
+		refreshPool:    refreshPool,
+		writeBehind:    writeBehind,
+		bloomFilter:    bloomFilter,
+		bloomSnapshot:  bloomSnapshot,
 		config:         config,
+		ttlRules:       ttlRules,
 		cacheCtx:       cacheCtx,
 		cancel:         cancel,
+		listenDone:     make(chan struct{}),
 		localOnlyKeys:  make(map[string]bool),
 	}
 
+	// 启动热点键探测
+	if config.HotKey.Enabled {
+		cache.hotKeys = newHotKeyDetector(config.HotKey)
+		go cache.hotKeys.run(cacheCtx)
+	}
+
+	// 启动写回队列定时刷新
+	if writeBehind != nil {
+		go writeBehind.run(cacheCtx)
+	}
+
+	// 启动布隆过滤器定时快照
+	if bloomSnapshot != nil {
+		bloomSnapshot.Start(cacheCtx)
+	}
+
 	// 启动消息监听goroutine
 	go cache.listenForSyncMessages()
 
 	return cache, nil
 }
 
+// BloomFilter 缓存内置的布隆过滤器，未启用时返回 nil
+func (dc *DistributedCache) BloomFilter() BloomFilter {
+	return dc.bloomFilter
+}
+
 // 关闭缓存实例
 func (dc *DistributedCache) Close() error {
 	dc.cancel()
@@ -118,14 +261,62 @@ func (dc *DistributedCache) Close() error {
 	if err := dc.pubSub.Close(); err != nil {
 		log.Printf("Error closing pubsub: %v", err)
 	}
+	// 等待监听goroutine退出，避免处理消息时访问已释放的一级缓存
+	<-dc.listenDone
+	// 停止接收刷新任务并等待进行中的刷新结束，之后才能关闭一二级缓存
+	dc.refreshPool.close()
+	if dc.writeBehind != nil {
+		if err := dc.writeBehind.close(); err != nil {
+			log.Printf("Error closing write-behind queue: %v", err)
+		}
+	}
+	// 先写入最后一次快照再关闭布隆过滤器
+	if dc.bloomSnapshot != nil {
+		if err := dc.bloomSnapshot.Stop(); err != nil {
+			log.Printf("Error saving bloom filter snapshot: %v", err)
+		}
+	}
+	if dc.bloomFilter != nil {
+		dc.bloomFilter.Close()
+	}
 	//  关闭缓存
 	dc.primaryCache.CleanUp()
 	dc.primaryCache = nil
 	return dc.secondaryCache.Close()
 }
 
+// refreshExecutor 一级缓存刷新任务的执行器，关闭后丢弃新任务并等待进行中的任务完成
+// gopool 的协程在任务队列为空时自行退出，因此等待任务完成即可停止协程池
+type refreshExecutor struct {
+	pool   gopool.Pool
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func (e *refreshExecutor) Go(f func()) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+	e.wg.Add(1)
+	e.pool.Go(func() {
+		defer e.wg.Done()
+		f()
+	})
+}
+
+func (e *refreshExecutor) close() {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+	e.wg.Wait()
+}
+
 // 监听同步消息
 func (dc *DistributedCache) listenForSyncMessages() {
+	defer close(dc.listenDone)
 	channel := dc.pubSub.Channel()
 	for {
 		select {
@@ -141,9 +332,9 @@ func (dc *DistributedCache) listenForSyncMessages() {
 }
 
 // 处理同步消息
-func (dc *DistributedCache) handleSyncMessage(msg *redis.Message) {
+func (dc *DistributedCache) handleSyncMessage(payload string) {
 	var syncMsg SyncMessage
-	if err := json.Unmarshal([]byte(msg.Payload), &syncMsg); err != nil {
+	if err := json.Unmarshal([]byte(payload), &syncMsg); err != nil {
 		log.Printf("Failed to unmarshal sync message: %v", err)
 		return
 	}
@@ -152,12 +343,17 @@ func (dc *DistributedCache) handleSyncMessage(msg *redis.Message) {
 	if syncMsg.InstanceID == dc.config.InstanceID {
 		return
 	}
+	dc.metrics.observeSyncLag(time.Duration(time.Now().UnixNano() - syncMsg.Timestamp))
 	// 处理消息
 	switch syncMsg.Operation {
 	case OperationDelete:
 		dc.silentDelete(syncMsg.Key)
 	case OperationSet:
 		dc.silentSet(syncMsg.Key, syncMsg.Value)
+	case OperationInvalidateKeys:
+		dc.invalidateLocalKeys(syncMsg.Keys)
+	case OperationInvalidatePrefix:
+		dc.invalidateLocalPrefix(syncMsg.Key)
 	}
 }
 
@@ -169,6 +365,7 @@ func (dc *DistributedCache) silentDelete(key string) {
 	// 标记为本地操作，避免循环广播
 	dc.localOnlyKeys[key] = true
 	dc.primaryCache.Invalidate(key)
+	dc.unpin(key)
 	delete(dc.localOnlyKeys, key)
 }
 
@@ -179,18 +376,23 @@ func (dc *DistributedCache) silentSet(key, value string) {
 	// 标记为本地操作，避免循环广播
 	dc.localOnlyKeys[key] = true
 	dc.primaryCache.Set(key, value)
+	dc.unpin(key)
 	delete(dc.localOnlyKeys, key)
 }
 
 // 发送同步消息
 func (dc *DistributedCache) sendSyncMessage(operation OperationType, key, value string) error {
-	syncMsg := SyncMessage{
-		Operation:  operation,
-		Key:        key,
-		Value:      value,
-		InstanceID: dc.config.InstanceID,
-		Timestamp:  time.Now().UnixNano(),
-	}
+	return dc.publishSyncMessage(SyncMessage{
+		Operation: operation,
+		Key:       key,
+		Value:     value,
+	})
+}
+
+// publishSyncMessage 补全来源实例和时间戳后广播同步消息
+func (dc *DistributedCache) publishSyncMessage(syncMsg SyncMessage) error {
+	syncMsg.InstanceID = dc.config.InstanceID
+	syncMsg.Timestamp = time.Now().UnixNano()
 
 	message, err := json.Marshal(syncMsg)
 	if err != nil {
@@ -198,40 +400,143 @@ func (dc *DistributedCache) sendSyncMessage(operation OperationType, key, value
 	}
 
 	dc.MsgSendCount.Add(1)
-	return dc.secondaryCache.Publish(dc.cacheCtx, dc.config.PubSubChannel, message).Err()
+	return dc.secondaryCache.Publish(dc.cacheCtx, dc.config.PubSubChannel, string(message))
 }
 
-// get 方法：一二级缓存读取
-func (dc *DistributedCache) get(key string) (string, error) {
-	// 1. 尝试从一级缓存获取
-	if value, err := dc.primaryCache.Get(dc.cacheCtx, key, nil); err != nil {
+// l1Loader 一级缓存加载器：先查二级缓存，未命中时调用数据源加载器并回写二级缓存
+// 一级缓存软过期后的异步刷新（Reload）同样走该加载器，刷新期间读请求继续返回旧值
+func (dc *DistributedCache) l1Loader(loader func(context.Context, string) (string, error)) otter.Loader[string, string] {
+	return otter.LoaderFunc[string, string](func(ctx context.Context, key string) (string, error) {
+		if dc.hotKeys != nil {
+			dc.hotKeys.record(key)
+		}
+		value, err := dc.secondaryCache.Get(ctx, key)
+		if err == nil {
+			dc.metrics.secondaryHits.Add(1)
+			return value, nil
+		}
+		dc.metrics.secondaryMisses.Add(1)
+		if err != ErrL2Nil {
+			if loader == nil {
+				return "", fmt.Errorf("redis error: %v", err)
+			}
+			log.Printf("Failed to get secondary cache, fallback to loader: %v", err)
+		}
+		if loader == nil {
+			return "", otter.ErrNotFound
+		}
+
+		start := time.Now()
+		value, err = loader(ctx, key)
+		dc.metrics.observeLoad(start, err)
+		if err != nil {
+			return "", err
+		}
+		// 回写二级缓存，其他实例的一级缓存通过各自的刷新获取新值
+		if err := dc.secondaryCache.Set(ctx, key, value, dc.ttlFor(key, dc.negativeTTL(value))); err != nil {
+			log.Printf("Failed to set secondary cache after loading: %v", err)
+		}
 		return value, nil
-	}
+	})
+}
 
-	// 2. 尝试从二级缓存获取
-	value, err := dc.secondaryCache.Get(dc.cacheCtx, key).Result()
-	if err == redis.Nil {
+// l1BulkLoader 一级缓存批量加载器，语义与 l1Loader 一致
+func (dc *DistributedCache) l1BulkLoader(loader func(context.Context, []string) ([]string, error)) otter.BulkLoader[string, string] {
+	return otter.BulkLoaderFunc[string, string](func(ctx context.Context, keys []string) (map[string]string, error) {
+		if dc.hotKeys != nil {
+			for _, key := range keys {
+				dc.hotKeys.record(key)
+			}
+		}
+		result := make(map[string]string, len(keys))
+		values, err := dc.secondaryCache.MGet(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		var missingKeys []string
+		for i, key := range keys {
+			if value, ok := values[i].(string); ok {
+				result[key] = value
+			} else {
+				missingKeys = append(missingKeys, key)
+			}
+		}
+		dc.metrics.secondaryHits.Add(uint64(len(result)))
+		dc.metrics.secondaryMisses.Add(uint64(len(missingKeys)))
+		if len(missingKeys) == 0 || loader == nil {
+			return result, nil
+		}
+
+		start := time.Now()
+		loaded, err := loader(ctx, missingKeys)
+		dc.metrics.observeLoad(start, err)
+		if err != nil {
+			return result, err
+		}
+		for i, key := range missingKeys {
+			result[key] = loaded[i]
+			if err := dc.secondaryCache.Set(ctx, key, loaded[i], dc.ttlFor(key, dc.negativeTTL(loaded[i]))); err != nil {
+				return result, fmt.Errorf("failed to set secondary cache: %v", err)
+			}
+		}
+		return result, nil
+	})
+}
+
+// get 方法：一二级缓存读取
+// 一级缓存未命中时同步加载；命中但已软过期时返回旧值并异步刷新；
+// 开启自动固定时热点键优先从本地固定缓存读取
+func (dc *DistributedCache) get(key string, loader func(context.Context, string) (string, error)) (string, error) {
+	load := func() (string, error) {
+		return dc.primaryCache.Get(dc.cacheCtx, key, dc.l1Loader(loader))
+	}
+	var value string
+	var err error
+	if dc.hotKeys != nil && dc.hotKeys.pinned != nil && dc.hotKeys.isHot(key) {
+		value, err = dc.getPinned(key, load)
+	} else {
+		value, err = load()
+	}
+	if errors.Is(err, otter.ErrNotFound) {
 		return "", fmt.Errorf("key not found: %s", key)
 	}
-	if err != nil {
-		return "", fmt.Errorf("redis error: %v", err)
+	if err == nil && value == "" {
+		// 命中加载失败时缓存的空值
+		dc.metrics.negativeHits.Add(1)
 	}
+	return value, err
+}
 
-	// 3. 回填一级缓存（静默操作，不广播）
-	dc.silentSet(key, value)
+// Set 一二级缓存写入，二级缓存过期时间按前缀规则或 RedisTTL 计算
+// 开启写回模式时同时加入写回队列
+func (dc *DistributedCache) Set(key, value string) error {
+	return dc.SetWithTTL(key, value, 0)
+}
 
-	return value, nil
+// SetWithTTL 一二级缓存写入并指定过期时间，ttl<=0 时与 Set 相同
+// ttl 小于一级缓存过期时间时一级缓存同样在 ttl 后过期；
+// 一级缓存按访问计时时，之后的读取会把过期时间重置为前缀规则或 OtterTTL
+// 写回模式下先入队再写缓存：入队失败时缓存不变；入队后写缓存失败时返回错误，但该值仍会写入后端存储
+func (dc *DistributedCache) SetWithTTL(key, value string, ttl time.Duration) error {
+	if err := dc.writeBack(key, value); err != nil {
+		return err
+	}
+	return dc.set(key, value, ttl)
 }
 
-// Set 方法：一二级缓存写入
-func (dc *DistributedCache) set(key, value string) error {
+// set 方法：一二级缓存写入
+func (dc *DistributedCache) set(key, value string, ttl time.Duration) error {
 	// 1. 先写入二级缓存（确保数据持久化）
-	if err := dc.secondaryCache.Set(dc.cacheCtx, key, value, dc.config.RedisTTL).Err(); err != nil {
+	if err := dc.secondaryCache.Set(dc.cacheCtx, key, value, dc.ttlFor(key, ttl)); err != nil {
 		return fmt.Errorf("failed to set secondary cache: %v", err)
 	}
 
 	// 2. 写入本地一级缓存
 	dc.primaryCache.Set(key, value)
+	dc.unpin(key)
+	if ttl > 0 && ttl < l1TTL(dc.config.OtterTTL, dc.ttlRules, key) {
+		dc.primaryCache.SetExpiresAfter(key, ttl)
+	}
 
 	// 3. 广播设置消息到其他实例（如果不是本地操作触发的）
 	dc.syncMutex.RLock()
@@ -250,13 +555,17 @@ func (dc *DistributedCache) set(key, value string) error {
 
 // Delete 方法：二级缓存删除
 func (dc *DistributedCache) Delete(key string) error {
+	// 0. 丢弃写回队列中该键尚未写入的旧值
+	dc.dropPendingWrites(func(k string) bool { return k == key })
+
 	// 1. 先删除二级缓存
-	if err := dc.secondaryCache.Del(dc.cacheCtx, key).Err(); err != nil {
+	if err := dc.secondaryCache.Del(dc.cacheCtx, []string{key}); err != nil {
 		return fmt.Errorf("failed to delete from secondary cache: %v", err)
 	}
 
 	// 2. 删除本地一级缓存
 	dc.primaryCache.Invalidate(key)
+	dc.unpin(key)
 
 	// 3. 广播删除消息到其他实例（如果不是本地操作触发的）
 	dc.syncMutex.RLock()
@@ -277,24 +586,19 @@ func (dc *DistributedCache) GetWithLoader(
 	loader func(context.Context, string) (string, error),
 ) (string, error) {
 
-	// 1. 尝试从缓存获取
-	if value, err := dc.get(key); err == nil {
+	// 1. 从缓存获取，未命中时由加载器从数据源加载并回填一二级缓存
+	value, err := dc.get(key, loader)
+	if err == nil {
 		return value, nil
 	}
 
-	// 2. 从数据源加载
-	value, err := loader(dc.cacheCtx, key)
-	if err != nil {
-		log.Println("Failed to load data:", err)
-		value = ""
-	}
-
-	// 3. 设置缓存
-	if err := dc.set(key, value); err != nil {
+	// 2. 加载失败时缓存空值，防止缓存穿透，空值在 NegativeTTL 后过期
+	log.Println("Failed to load data:", err)
+	if err := dc.set(key, "", dc.config.NegativeTTL); err != nil {
 		log.Printf("Failed to set cache after loading: %v", err)
 	}
 
-	return value, nil
+	return "", nil
 }
 
 //// 批量操作支持
@@ -335,73 +639,8 @@ func (dc *DistributedCache) GetWithLoader(
 //	return result, nil
 //}
 
+// MGetWithLoader 批量获取，一级缓存未命中的键先查二级缓存，仍未命中的交给加载器
+// 加载失败时返回已获取的部分结果和错误
 func (dc *DistributedCache) MGetWithLoader(keys []string, loader func(context context.Context, key []string) ([]string, error)) (map[string]string, error) {
-	// 1. 先从一级缓存获取可用数据
-	result, err := dc.primaryCache.BulkGet(dc.cacheCtx, keys, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// 2. 检查未命中的键
-	var missingKeysPrimary []string
-	for _, key := range keys {
-		if _, found := result[key]; !found {
-			missingKeysPrimary = append(missingKeysPrimary, key)
-		}
-	}
-
-	var missingKeysSecond []string
-	// 3. 从二级缓存获取缺失的键
-	if len(missingKeysPrimary) > 0 {
-		values, err := dc.secondaryCache.MGet(dc.cacheCtx, missingKeysPrimary...).Result()
-		if err != nil {
-			return nil, err
-		}
-		for i, key := range missingKeysPrimary {
-			if values[i] != nil {
-				value := values[i].(string)
-				result[key] = value
-				// 回填一级缓存
-				dc.silentSet(key, value)
-			} else {
-				missingKeysSecond = append(missingKeysSecond, key)
-			}
-		}
-	}
-	if len(missingKeysSecond) > 0 {
-		strings, err := loader(dc.cacheCtx, missingKeysSecond)
-		if err != nil {
-			return result, err
-		}
-		for i, key := range missingKeysSecond {
-			result[key] = strings[i]
-			err := dc.set(key, strings[i])
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	return result, nil
-}
-
-// 缓存统计信息
-type CacheStats struct {
-	PrimaryHits     uint64 `json:"primaryHits"`
-	PrimaryMisses   uint64 `json:"primaryMisses"`
-	SecondaryHits   uint64 `json:"secondaryHits"`
-	SecondaryMisses uint64 `json:"secondaryMisses"`
-	MessagesSent    uint64 `json:"syncMessagesSent"`
-	MessagesRecvd   uint64 `json:"syncMessagesReceived"`
-}
-
-func (dc *DistributedCache) GetStats() *CacheStats {
-	// 实现统计信息收集
-	return &CacheStats{
-		// 填充统计信息
-		PrimaryHits:   dc.primaryCache.Stats().Hits,
-		PrimaryMisses: dc.primaryCache.Stats().Misses,
-		MessagesSent:  dc.MsgSendCount.Load(),
-		MessagesRecvd: dc.MsgRecvdCount.Load(),
-	}
+	return dc.primaryCache.BulkGet(dc.cacheCtx, keys, dc.l1BulkLoader(loader))
 }
@@ -0,0 +1,402 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, client L2Client, instanceID string) *DistributedCache {
+	t.Helper()
+	dc, err := NewDistributedCacheWithClient(context.Background(), Config{
+		OtterMaxSize:  1000,
+		OtterTTL:      time.Minute,
+		RedisTTL:      time.Minute,
+		PubSubChannel: "cache:sync",
+		InstanceID:    instanceID,
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dc.Close() })
+	return dc
+}
+
+// newTestCachePair 创建共享同一个二级缓存的两个实例
+func newTestCachePair(t *testing.T) (*DistributedCache, *DistributedCache, *MemoryL2Client) {
+	client := NewMemoryL2Client()
+	return newTestCache(t, client, "a"), newTestCache(t, client, "b"), client
+}
+
+// eventually 等待异步的同步消息生效
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func cachedValue(dc *DistributedCache, key string) string {
+	value, _ := dc.get(key, nil)
+	return value
+}
+
+func TestMemoryL2ClientTTL(t *testing.T) {
+	ctx := context.Background()
+	client := NewMemoryL2Client()
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	client.Set(ctx, "k", "v", time.Second)
+	client.Set(ctx, "forever", "v", 0)
+	if value, err := client.Get(ctx, "k"); err != nil || value != "v" {
+		t.Fatalf("get before expiry: %q %v", value, err)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := client.Get(ctx, "k"); err != ErrL2Nil {
+		t.Fatalf("get after expiry: %v", err)
+	}
+	values, _ := client.MGet(ctx, []string{"k", "forever"})
+	if values[0] != nil || values[1] != "v" {
+		t.Fatalf("mget: %v", values)
+	}
+}
+
+func TestMemoryL2ClientSAddOnlyExtendsTTL(t *testing.T) {
+	ctx := context.Background()
+	client := NewMemoryL2Client()
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	client.SAdd(ctx, "s", []string{"a"}, time.Hour)
+	// 较短的 ttl 不会缩短集合过期时间
+	client.SAdd(ctx, "s", []string{"b"}, time.Minute)
+	now = now.Add(30 * time.Minute)
+	if members, _ := client.SMembers(ctx, "s"); len(members) != 2 {
+		t.Fatalf("set shortened by smaller ttl: %v", members)
+	}
+	// 较长的 ttl 延长过期时间
+	client.SAdd(ctx, "s", []string{"c"}, 2*time.Hour)
+	now = now.Add(time.Hour)
+	if members, _ := client.SMembers(ctx, "s"); len(members) != 3 {
+		t.Fatalf("set not extended by larger ttl: %v", members)
+	}
+	// ttl<=0 时集合永不过期，之后带 ttl 的添加也不再设置过期时间
+	client.SAdd(ctx, "s", []string{"d"}, 0)
+	client.SAdd(ctx, "s", []string{"e"}, time.Minute)
+	now = now.Add(24 * time.Hour)
+	if members, _ := client.SMembers(ctx, "s"); len(members) != 5 {
+		t.Fatalf("persistent set expired: %v", members)
+	}
+}
+
+func TestMemoryL2ClientPubSub(t *testing.T) {
+	ctx := context.Background()
+	client := NewMemoryL2Client()
+	sub1 := client.Subscribe(ctx, "ch")
+	sub2 := client.Subscribe(ctx, "ch")
+	other := client.Subscribe(ctx, "other")
+
+	client.Publish(ctx, "ch", "hello")
+	for _, sub := range []L2Subscription{sub1, sub2} {
+		if msg := <-sub.Channel(); msg != "hello" {
+			t.Fatalf("unexpected message: %q", msg)
+		}
+	}
+	select {
+	case msg := <-other.Channel():
+		t.Fatalf("message leaked to other channel: %q", msg)
+	default:
+	}
+
+	sub1.Close()
+	if _, ok := <-sub1.Channel(); ok {
+		t.Fatal("channel should be closed")
+	}
+	client.Publish(ctx, "ch", "after close")
+	if msg := <-sub2.Channel(); msg != "after close" {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestSetVisibleToOtherInstance(t *testing.T) {
+	a, b, _ := newTestCachePair(t)
+
+	if err := a.Set("user:1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if value := cachedValue(b, "user:1"); value != "alice" {
+		t.Fatalf("b got %q", value)
+	}
+}
+
+func TestSetInvalidatesOtherInstance(t *testing.T) {
+	a, b, _ := newTestCachePair(t)
+
+	a.Set("user:1", "alice")
+	// b 读取后一级缓存中持有旧值
+	if value := cachedValue(b, "user:1"); value != "alice" {
+		t.Fatalf("b got %q", value)
+	}
+
+	a.Set("user:1", "bob")
+	eventually(t, func() bool { return cachedValue(b, "user:1") == "bob" })
+	if b.GetStats().MessagesRecvd == 0 {
+		t.Error("b should have received sync messages")
+	}
+}
+
+func TestDeleteInvalidatesOtherInstance(t *testing.T) {
+	a, b, _ := newTestCachePair(t)
+
+	a.Set("user:1", "alice")
+	cachedValue(b, "user:1")
+
+	if err := a.Delete("user:1"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool {
+		_, err := b.get("user:1", nil)
+		return err != nil
+	})
+}
+
+func TestInvalidateTag(t *testing.T) {
+	a, b, client := newTestCachePair(t)
+
+	a.SetWithTags("user:42:profile", "p", "user:42")
+	a.SetWithTags("user:42:orders", "o", "user:42")
+	a.Set("user:43:profile", "q")
+	for _, key := range []string{"user:42:profile", "user:42:orders"} {
+		cachedValue(b, key)
+	}
+
+	if err := a.InvalidateTag("user:42"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"user:42:profile", "user:42:orders"} {
+		if _, err := client.Get(context.Background(), key); err != ErrL2Nil {
+			t.Fatalf("%s still in L2: %v", key, err)
+		}
+		key := key
+		eventually(t, func() bool {
+			_, err := b.get(key, nil)
+			return err != nil
+		})
+	}
+	if value := cachedValue(b, "user:43:profile"); value != "q" {
+		t.Fatalf("untagged key invalidated: %q", value)
+	}
+}
+
+func TestInvalidateTagAfterShorterTTLKey(t *testing.T) {
+	client := NewMemoryL2Client()
+	now := time.Now()
+	client.now = func() time.Time { return now }
+	dc := newTestCache(t, client, "a")
+
+	dc.SetWithTTLAndTags("user:1:profile", "p", time.Hour, "user:1")
+	dc.SetWithTTLAndTags("user:1:session", "s", time.Second, "user:1")
+	now = now.Add(time.Minute)
+	// 短 ttl 的键不会缩短标签集合的过期时间，长 ttl 的键仍能按标签失效
+	if err := dc.InvalidateTag("user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(context.Background(), "user:1:profile"); err != ErrL2Nil {
+		t.Fatalf("profile not invalidated: %v", err)
+	}
+}
+
+func TestInvalidatePrefix(t *testing.T) {
+	a, b, _ := newTestCachePair(t)
+
+	a.Set("order:1", "x")
+	a.Set("order:2", "y")
+	a.Set("user:1", "z")
+	cachedValue(b, "order:1")
+	cachedValue(b, "order:2")
+
+	if err := a.InvalidatePrefix("order:"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool {
+		_, err1 := b.get("order:1", nil)
+		_, err2 := b.get("order:2", nil)
+		return err1 != nil && err2 != nil
+	})
+	if value := cachedValue(b, "user:1"); value != "z" {
+		t.Fatalf("key outside prefix invalidated: %q", value)
+	}
+}
+
+func TestGetWithLoader(t *testing.T) {
+	a, b, _ := newTestCachePair(t)
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context, key string) (string, error) {
+		calls.Add(1)
+		return "loaded:" + key, nil
+	}
+	for _, dc := range []*DistributedCache{a, a, b} {
+		value, err := dc.GetWithLoader("k", loader)
+		if err != nil || value != "loaded:k" {
+			t.Fatalf("got %q %v", value, err)
+		}
+	}
+	// b 命中 a 回写的二级缓存，不再调用加载器
+	if calls.Load() != 1 {
+		t.Fatalf("loader called %d times", calls.Load())
+	}
+	if stats := b.GetStats(); stats.SecondaryHits != 1 {
+		t.Fatalf("b secondary hits: %d", stats.SecondaryHits)
+	}
+}
+
+func TestGetWithLoaderCachesEmptyValueOnError(t *testing.T) {
+	a, _, _ := newTestCachePair(t)
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context, key string) (string, error) {
+		calls.Add(1)
+		return "", errors.New("db down")
+	}
+	for i := 0; i < 2; i++ {
+		if value, err := a.GetWithLoader("missing", loader); err != nil || value != "" {
+			t.Fatalf("got %q %v", value, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("loader called %d times", calls.Load())
+	}
+	if stats := a.GetStats(); stats.NegativeHits != 1 || stats.LoaderErrors != 1 {
+		t.Fatalf("stats: %+v", stats)
+	}
+}
+
+func TestMGetWithLoader(t *testing.T) {
+	a, b, _ := newTestCachePair(t)
+
+	a.Set("k1", "v1")
+	var loadedKeys []string
+	result, err := b.MGetWithLoader([]string{"k1", "k2", "k3"}, func(ctx context.Context, keys []string) ([]string, error) {
+		loadedKeys = keys
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = fmt.Sprintf("loaded:%s", key)
+		}
+		return values, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loadedKeys) != 2 {
+		t.Fatalf("loader should only load missing keys, got %v", loadedKeys)
+	}
+	want := map[string]string{"k1": "v1", "k2": "loaded:k2", "k3": "loaded:k3"}
+	for key, value := range want {
+		if result[key] != value {
+			t.Fatalf("%s: got %q want %q", key, result[key], value)
+		}
+	}
+	// 加载结果已回写二级缓存，另一个实例可直接读取
+	if value := cachedValue(a, "k3"); value != "loaded:k3" {
+		t.Fatalf("a got %q", value)
+	}
+}
+
+func TestCloseStopsRefreshPool(t *testing.T) {
+	dc, err := NewDistributedCacheWithClient(context.Background(), Config{
+		OtterMaxSize:      1000,
+		OtterTTL:          time.Minute,
+		OtterRefreshAfter: time.Second,
+		RedisTTL:          time.Minute,
+		PubSubChannel:     "cache:sync",
+		InstanceID:        "a",
+	}, NewMemoryL2Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	dc.refreshPool.Go(func() {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+	})
+	<-started
+	// Close 等待进行中的刷新任务结束
+	dc.Close()
+	if !finished.Load() {
+		t.Fatal("Close returned before in-flight refresh finished")
+	}
+	// 关闭后提交的刷新任务被丢弃
+	var ran atomic.Bool
+	dc.refreshPool.Go(func() { ran.Store(true) })
+	time.Sleep(20 * time.Millisecond)
+	if ran.Load() {
+		t.Fatal("refresh task ran after Close")
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	dc, err := NewDistributedCacheWithClient(context.Background(), Config{
+		OtterMaxSize:      1000,
+		OtterTTL:          time.Minute,
+		OtterRefreshAfter: 20 * time.Millisecond,
+		RedisTTL:          time.Minute,
+		PubSubChannel:     "cache:sync",
+		InstanceID:        "a",
+	}, NewMemoryL2Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dc.Close()
+
+	var version atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (string, error) {
+		if version.Add(1) > 1 {
+			// 刷新时阻塞，验证刷新期间读取不等待加载器
+			<-release
+		}
+		return fmt.Sprintf("v%d", version.Load()), nil
+	}
+
+	if value, err := dc.GetWithLoader("k", loader); err != nil || value != "v1" {
+		t.Fatalf("first load: %q %v", value, err)
+	}
+	// 删除二级缓存，使刷新走到加载器
+	dc.secondaryCache.Del(context.Background(), []string{"k"})
+	time.Sleep(40 * time.Millisecond)
+
+	// 软过期后读取立即返回旧值，同时触发异步刷新
+	done := make(chan string, 1)
+	go func() {
+		value, _ := dc.GetWithLoader("k", loader)
+		done <- value
+	}()
+	select {
+	case value := <-done:
+		if value != "v1" {
+			t.Fatalf("stale read: got %q", value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read blocked on refresh")
+	}
+	eventually(t, func() bool { return version.Load() == 2 })
+	if value := cachedValue(dc, "k"); value != "v1" {
+		t.Fatalf("read during refresh: got %q", value)
+	}
+
+	close(release)
+	eventually(t, func() bool { return cachedValue(dc, "k") == "v2" })
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"context"
+	"hash/maphash"
+	"log"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maypok86/otter/v2"
+	"github.com/maypok86/otter/v2/stats"
+)
+
+// 热点键探测默认配置
+const (
+	defaultHotKeySampleRate = 1.0
+	defaultHotKeyWindow     = 10 * time.Second
+	defaultHotKeyThreshold  = 100
+	defaultHotKeyTopK       = 32
+	defaultHotKeyPinTTL     = time.Second
+
+	cmSketchDepth = 4
+	cmSketchWidth = 2048
+)
+
+// HotKeyConfig 热点键探测配置
+type HotKeyConfig struct {
+	Enabled    bool          `json:"enabled"`
+	SampleRate float64       `json:"sampleRate"` // 采样率 (0,1]，访问量很大时降低采样开销
+	Window     time.Duration `json:"window"`     // 统计窗口，每个窗口结束时计数减半
+	Threshold  uint64        `json:"threshold"`  // 窗口内估算访问次数达到该值视为热点
+	TopK       int           `json:"topK"`       // 最多跟踪的热点键数量
+	AutoPin    bool          `json:"autoPin"`    // 是否自动将热点键固定到本地
+	PinTTL     time.Duration `json:"pinTTL"`     // 固定到本地的过期时间，应较短以控制数据不一致窗口
+}
+
+func (c *HotKeyConfig) withDefaults() HotKeyConfig {
+	cfg := *c
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		cfg.SampleRate = defaultHotKeySampleRate
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultHotKeyWindow
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = defaultHotKeyThreshold
+	}
+	if cfg.TopK <= 0 {
+		cfg.TopK = defaultHotKeyTopK
+	}
+	if cfg.PinTTL <= 0 {
+		cfg.PinTTL = defaultHotKeyPinTTL
+	}
+	return cfg
+}
+
+// HotKey 热点键及其窗口内估算访问次数
+type HotKey struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// countMinSketch Count-Min Sketch 频率估算，只会高估不会低估
+type countMinSketch struct {
+	seeds    [cmSketchDepth]maphash.Seed
+	counters [cmSketchDepth][cmSketchWidth]uint32
+}
+
+func newCountMinSketch() *countMinSketch {
+	s := &countMinSketch{}
+	for i := range s.seeds {
+		s.seeds[i] = maphash.MakeSeed()
+	}
+	return s
+}
+
+// add 计数加 delta 并返回新的估算值
+func (s *countMinSketch) add(key string, delta uint32) uint64 {
+	estimate := uint32(0)
+	for i := range s.seeds {
+		idx := maphash.String(s.seeds[i], key) % cmSketchWidth
+		s.counters[i][idx] += delta
+		if i == 0 || s.counters[i][idx] < estimate {
+			estimate = s.counters[i][idx]
+		}
+	}
+	return uint64(estimate)
+}
+
+// decay 所有计数减半，使旧窗口的访问逐步失去影响
+func (s *countMinSketch) decay() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+}
+
+// hotKeyDetector 基于采样 + Count-Min Sketch + Top-K 的热点键探测器
+type hotKeyDetector struct {
+	config  HotKeyConfig
+	weight  uint32 // 每次采样代表的访问次数
+	mu      sync.Mutex
+	sketch  *countMinSketch
+	topK    map[string]uint64
+	pinned  *otter.Cache[string, string] // 固定在本地的热点键
+	onFound func(key string, count uint64)
+}
+
+func newHotKeyDetector(config HotKeyConfig) *hotKeyDetector {
+	cfg := config.withDefaults()
+	d := &hotKeyDetector{
+		config: cfg,
+		weight: uint32(math.Round(1 / cfg.SampleRate)),
+		sketch: newCountMinSketch(),
+		topK:   make(map[string]uint64, cfg.TopK),
+		onFound: func(key string, count uint64) {
+			log.Printf("Hot key detected: %s, estimated count: %d", key, count)
+		},
+	}
+	if cfg.AutoPin {
+		d.pinned = otter.Must(&otter.Options[string, string]{
+			MaximumSize:      cfg.TopK * 2,
+			ExpiryCalculator: otter.ExpiryWriting[string, string](cfg.PinTTL),
+			StatsRecorder:    stats.NewCounter(),
+		})
+	}
+	return d
+}
+
+// record 记录一次访问（按采样率）
+func (d *hotKeyDetector) record(key string) {
+	if d.config.SampleRate < 1 && rand.Float64() >= d.config.SampleRate {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	estimate := d.sketch.add(key, d.weight)
+	if estimate < d.config.Threshold {
+		return
+	}
+	if _, ok := d.topK[key]; ok {
+		d.topK[key] = estimate
+		return
+	}
+	if len(d.topK) >= d.config.TopK {
+		// 替换 Top-K 中访问次数最少的键
+		minKey, minCount := "", uint64(0)
+		for k, c := range d.topK {
+			if minKey == "" || c < minCount {
+				minKey, minCount = k, c
+			}
+		}
+		if estimate <= minCount {
+			return
+		}
+		delete(d.topK, minKey)
+	}
+	d.topK[key] = estimate
+	d.onFound(key, estimate)
+}
+
+// isHot 判断键当前是否为热点
+func (d *hotKeyDetector) isHot(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.topK[key]
+	return ok
+}
+
+// decay 窗口结束时衰减计数，并移除低于阈值的热点键
+func (d *hotKeyDetector) decay() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sketch.decay()
+	for key, count := range d.topK {
+		count >>= 1
+		if count < d.config.Threshold {
+			delete(d.topK, key)
+		} else {
+			d.topK[key] = count
+		}
+	}
+}
+
+// hotKeys 返回当前热点键，按访问次数降序
+func (d *hotKeyDetector) hotKeys() []HotKey {
+	d.mu.Lock()
+	keys := make([]HotKey, 0, len(d.topK))
+	for key, count := range d.topK {
+		keys = append(keys, HotKey{Key: key, Count: count})
+	}
+	d.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Count > keys[j].Count
+	})
+	return keys
+}
+
+// run 按窗口周期衰减计数，直到 ctx 结束
+func (d *hotKeyDetector) run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.decay()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// HotKeys 返回当前探测到的热点键，未启用探测时返回 nil
+func (dc *DistributedCache) HotKeys() []HotKey {
+	if dc.hotKeys == nil {
+		return nil
+	}
+	return dc.hotKeys.hotKeys()
+}
+
+// getPinned 热点键经本地固定缓存读取：命中直接返回，未命中时同一键的并发请求合并为一次加载
+// 本实例写入删除及其他实例广播的失效都会移除固定的热点键，PinTTL 兜底丢失的失效消息
+func (dc *DistributedCache) getPinned(key string, load func() (string, error)) (string, error) {
+	return dc.hotKeys.pinned.Get(dc.cacheCtx, key, otter.LoaderFunc[string, string](
+		func(ctx context.Context, key string) (string, error) {
+			return load()
+		}))
+}
+
+// unpin 写入、删除或收到失效消息时移除本地固定的热点键，下次读取重新加载
+func (dc *DistributedCache) unpin(key string) {
+	if dc.hotKeys != nil && dc.hotKeys.pinned != nil {
+		dc.hotKeys.pinned.Invalidate(key)
+	}
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestDetector(threshold uint64, topK int) *hotKeyDetector {
+	d := newHotKeyDetector(HotKeyConfig{Enabled: true, SampleRate: 1, Threshold: threshold, TopK: topK})
+	d.onFound = func(string, uint64) {}
+	return d
+}
+
+func TestCountMinSketchAddAndDecay(t *testing.T) {
+	s := newCountMinSketch()
+	for i := 0; i < 3; i++ {
+		s.add("a", 1)
+	}
+	if got := s.add("b", 5); got != 5 {
+		t.Fatalf("b: got %d", got)
+	}
+	if got := s.add("a", 0); got != 3 {
+		t.Fatalf("a: got %d", got)
+	}
+	s.decay()
+	if got := s.add("a", 0); got != 1 {
+		t.Fatalf("a after decay: got %d", got)
+	}
+	if got := s.add("b", 0); got != 2 {
+		t.Fatalf("b after decay: got %d", got)
+	}
+}
+
+func TestHotKeyDetectorWeightRounds(t *testing.T) {
+	cases := map[float64]uint32{1: 1, 0.5: 2, 0.6: 2, 0.3: 3, 0.01: 100}
+	for rate, want := range cases {
+		d := newHotKeyDetector(HotKeyConfig{SampleRate: rate})
+		if d.weight != want {
+			t.Fatalf("sample rate %v: got weight %d want %d", rate, d.weight, want)
+		}
+	}
+}
+
+func TestHotKeyDetectorTopKReplacement(t *testing.T) {
+	d := newTestDetector(2, 2)
+	var found []string
+	d.onFound = func(key string, count uint64) { found = append(found, key) }
+	for i := 0; i < 3; i++ {
+		d.record("a")
+	}
+	for i := 0; i < 4; i++ {
+		d.record("b")
+	}
+	// Top-K 已满，c 的访问次数未超过最少的 a 时不替换
+	for i := 0; i < 3; i++ {
+		d.record("c")
+	}
+	if d.isHot("c") || !d.isHot("a") {
+		t.Fatalf("c should not replace a yet: %v", d.hotKeys())
+	}
+	d.record("c")
+	if !d.isHot("c") || d.isHot("a") || !d.isHot("b") {
+		t.Fatalf("c should replace a: %v", d.hotKeys())
+	}
+	if len(found) != 3 || found[2] != "c" {
+		t.Fatalf("found: %v", found)
+	}
+	if keys := d.hotKeys(); len(keys) != 2 || keys[0].Count != 4 || keys[1].Count != 4 {
+		t.Fatalf("hot keys: %v", keys)
+	}
+}
+
+func TestHotKeyDetectorDecayEvictsBelowThreshold(t *testing.T) {
+	d := newTestDetector(4, 8)
+	for i := 0; i < 8; i++ {
+		d.record("a")
+	}
+	for i := 0; i < 5; i++ {
+		d.record("b")
+	}
+	d.decay()
+	if !d.isHot("a") {
+		t.Fatal("a should stay hot after decay")
+	}
+	if d.isHot("b") {
+		t.Fatal("b should be evicted after decay")
+	}
+	if keys := d.hotKeys(); len(keys) != 1 || keys[0].Count != 4 {
+		t.Fatalf("hot keys: %v", keys)
+	}
+}
+
+func newHotKeyTestCache(t *testing.T, client L2Client, instanceID string) *DistributedCache {
+	t.Helper()
+	dc, err := NewDistributedCacheWithClient(context.Background(), Config{
+		OtterMaxSize:  1000,
+		OtterTTL:      time.Minute,
+		RedisTTL:      time.Minute,
+		HotKey:        HotKeyConfig{Enabled: true, SampleRate: 1, Threshold: 1, AutoPin: true, PinTTL: time.Minute},
+		PubSubChannel: "cache:sync",
+		InstanceID:    instanceID,
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc.hotKeys.onFound = func(string, uint64) {}
+	t.Cleanup(func() { dc.Close() })
+	return dc
+}
+
+func TestGetPinnedAndUnpin(t *testing.T) {
+	dc := newHotKeyTestCache(t, NewMemoryL2Client(), "a")
+	dc.Set("k", "v1")
+	dc.hotKeys.record("k")
+	if value := cachedValue(dc, "k"); value != "v1" {
+		t.Fatalf("got %q", value)
+	}
+	if _, ok := dc.hotKeys.pinned.GetIfPresent("k"); !ok {
+		t.Fatal("hot key should be pinned")
+	}
+
+	// 绕过 unpin 修改一级缓存，固定的值仍被返回
+	dc.primaryCache.Set("k", "v2")
+	if value := cachedValue(dc, "k"); value != "v1" {
+		t.Fatalf("pinned value expected, got %q", value)
+	}
+	dc.unpin("k")
+	if value := cachedValue(dc, "k"); value != "v2" {
+		t.Fatalf("after unpin: got %q", value)
+	}
+}
+
+func TestRemoteInvalidationUnpins(t *testing.T) {
+	client := NewMemoryL2Client()
+	a := newHotKeyTestCache(t, client, "a")
+	b := newHotKeyTestCache(t, client, "b")
+
+	a.Set("k", "v1")
+	b.hotKeys.record("k")
+	if value := cachedValue(b, "k"); value != "v1" {
+		t.Fatalf("got %q", value)
+	}
+	a.Set("k", "v2")
+	// 其他实例广播的失效移除固定的旧值
+	eventually(t, func() bool { return cachedValue(b, "k") == "v2" })
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// 标签集合在 Redis 中的键前缀，集合成员为打了该标签的缓存键
+	tagKeyPrefix = "cache:tag:"
+	// 按前缀扫描/删除二级缓存时每批处理的键数量
+	invalidateBatchSize = 500
+)
+
+func tagKey(tag string) string {
+	return tagKeyPrefix + tag
+}
+
+// SetWithTags 一二级缓存写入，并为键打上标签（如 user:42 派生出的所有键都打上 user:42）
+// 之后可通过 InvalidateTag 一次性失效同一标签下的全部键
+func (dc *DistributedCache) SetWithTags(key, value string, tags ...string) error {
+	return dc.SetWithTTLAndTags(key, value, 0, tags...)
+}
+
+// SetWithTTLAndTags 指定过期时间写入并打标签，ttl<=0 时按前缀规则或 RedisTTL 计算
+func (dc *DistributedCache) SetWithTTLAndTags(key, value string, ttl time.Duration, tags ...string) error {
+	if err := dc.SetWithTTL(key, value, ttl); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	// SAdd 只延长标签集合的过期时间，集合不会早于其中任一键过期；键过期后残留的成员在失效时删除空键即可
+	tagTTL := dc.ttlFor(key, ttl)
+	for _, tag := range tags {
+		if err := dc.secondaryCache.SAdd(dc.cacheCtx, tagKey(tag), []string{key}, tagTTL); err != nil {
+			return fmt.Errorf("failed to tag key %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// InvalidateTag 失效标签下的全部键：丢弃写回队列中这些键的待写入值，删除二级缓存中的键和标签集合，
+// 并广播一条批量失效消息，由各实例清理一级缓存
+func (dc *DistributedCache) InvalidateTag(tag string) error {
+	keys, err := dc.secondaryCache.SMembers(dc.cacheCtx, tagKey(tag))
+	if err != nil {
+		return fmt.Errorf("failed to get tag members: %v", err)
+	}
+	if len(keys) > 0 {
+		tagged := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			tagged[key] = true
+		}
+		dc.dropPendingWrites(func(key string) bool { return tagged[key] })
+	}
+	if err := dc.deleteSecondaryKeys(append(keys, tagKey(tag))); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	dc.invalidateLocalKeys(keys)
+	if err := dc.publishSyncMessage(SyncMessage{Operation: OperationInvalidateKeys, Keys: keys}); err != nil {
+		log.Printf("Failed to send sync message: %v", err)
+	}
+	return nil
+}
+
+// InvalidatePrefix 失效以 prefix 开头的全部键：丢弃写回队列中匹配的待写入值，SCAN 删除二级缓存中的键（集群模式下遍历所有主节点），
+// 并广播一条前缀失效消息，由各实例清理一级缓存
+func (dc *DistributedCache) InvalidatePrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("invalidate prefix must not be empty")
+	}
+	dc.dropPendingWrites(func(key string) bool { return strings.HasPrefix(key, prefix) })
+	if err := dc.secondaryCache.ScanPrefix(dc.cacheCtx, prefix, dc.deleteSecondaryKeys); err != nil {
+		return fmt.Errorf("failed to scan secondary cache: %v", err)
+	}
+
+	dc.invalidateLocalPrefix(prefix)
+	if err := dc.publishSyncMessage(SyncMessage{Operation: OperationInvalidatePrefix, Key: prefix}); err != nil {
+		log.Printf("Failed to send sync message: %v", err)
+	}
+	return nil
+}
+
+// deleteSecondaryKeys 删除二级缓存中的键
+func (dc *DistributedCache) deleteSecondaryKeys(keys []string) error {
+	if err := dc.secondaryCache.Del(dc.cacheCtx, keys); err != nil {
+		return fmt.Errorf("failed to delete from secondary cache: %v", err)
+	}
+	return nil
+}
+
+// invalidateLocalKeys 静默失效一级缓存及本地固定的热点键（不触发消息广播）
+func (dc *DistributedCache) invalidateLocalKeys(keys []string) {
+	for _, key := range keys {
+		dc.silentDelete(key)
+	}
+}
+
+// invalidateLocalPrefix 静默失效一级缓存中以 prefix 开头的键（不触发消息广播）
+func (dc *DistributedCache) invalidateLocalPrefix(prefix string) {
+	var keys []string
+	for key := range dc.primaryCache.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	if dc.hotKeys != nil && dc.hotKeys.pinned != nil {
+		for key := range dc.hotKeys.pinned.Keys() {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	dc.invalidateLocalKeys(keys)
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrL2Nil 二级缓存中键不存在，与 redis.Nil 相同以便统一判断
+var ErrL2Nil = redis.Nil
+
+// L2Client 二级缓存客户端抽象
+// 生产环境使用 Redis 实现（见 NewRedisL2Client），测试可使用进程内实现 MemoryL2Client
+type L2Client interface {
+	Ping(ctx context.Context) error
+	// Get 获取键值，键不存在时返回 ErrL2Nil
+	Get(ctx context.Context, key string) (string, error)
+	// Set 写入键值，ttl<=0 表示永不过期
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// MGet 批量获取，返回值与 keys 一一对应，不存在的键为 nil
+	MGet(ctx context.Context, keys []string) ([]interface{}, error)
+	// Del 删除键
+	Del(ctx context.Context, keys []string) error
+	// SAdd 向集合添加成员，集合过期时间只延长不缩短，ttl<=0 时集合永不过期
+	SAdd(ctx context.Context, key string, members []string, ttl time.Duration) error
+	// SMembers 获取集合全部成员，集合不存在时返回空
+	SMembers(ctx context.Context, key string) ([]string, error)
+	// ScanPrefix 分批遍历以 prefix 开头的键
+	ScanPrefix(ctx context.Context, prefix string, fn func(keys []string) error) error
+	// Publish 向频道发布消息
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe 订阅频道
+	Subscribe(ctx context.Context, channel string) L2Subscription
+	Close() error
+}
+
+// L2Subscription 频道订阅
+type L2Subscription interface {
+	// Channel 返回消息内容通道，订阅关闭后通道关闭
+	Channel() <-chan string
+	Close() error
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 订阅通道缓冲大小，消费过慢时丢弃消息（与 Redis 断开慢消费者的行为类似）
+const memorySubscriptionBuffer = 1024
+
+// memoryEntry 内存二级缓存条目，value 与 set 二选一
+type memoryEntry struct {
+	value    string
+	set      map[string]struct{}
+	expireAt time.Time // 零值表示永不过期
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// MemoryL2Client 进程内二级缓存实现，支持 GET/SET/MGET/DEL/集合/PUBLISH/SUBSCRIBE 及过期时间
+// 用于测试：多个 DistributedCache 共享同一个实例即可模拟多实例部署，无需启动 Redis
+type MemoryL2Client struct {
+	mu          sync.Mutex
+	data        map[string]*memoryEntry
+	subscribers map[string]map[*memorySubscription]struct{}
+	now         func() time.Time
+}
+
+// NewMemoryL2Client 创建进程内二级缓存
+func NewMemoryL2Client() *MemoryL2Client {
+	return &MemoryL2Client{
+		data:        make(map[string]*memoryEntry),
+		subscribers: make(map[string]map[*memorySubscription]struct{}),
+		now:         time.Now,
+	}
+}
+
+// load 获取未过期的条目，已过期的顺带删除，调用方需持有锁
+func (m *MemoryL2Client) load(key string) (*memoryEntry, bool) {
+	entry, ok := m.data[key]
+	if !ok {
+		return nil, false
+	}
+	if entry.expired(m.now()) {
+		delete(m.data, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (m *MemoryL2Client) expireAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
+
+func (m *MemoryL2Client) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryL2Client) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.load(key)
+	if !ok || entry.set != nil {
+		return "", ErrL2Nil
+	}
+	return entry.value, nil
+}
+
+func (m *MemoryL2Client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = &memoryEntry{value: value, expireAt: m.expireAt(ttl)}
+	return nil
+}
+
+func (m *MemoryL2Client) MGet(ctx context.Context, keys []string) ([]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if entry, ok := m.load(key); ok && entry.set == nil {
+			values[i] = entry.value
+		}
+	}
+	return values, nil
+}
+
+func (m *MemoryL2Client) Del(ctx context.Context, keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.data, key)
+	}
+	return nil
+}
+
+func (m *MemoryL2Client) SAdd(ctx context.Context, key string, members []string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(members) == 0 {
+		return nil
+	}
+	entry, existed := m.load(key)
+	if !existed || entry.set == nil {
+		entry, existed = &memoryEntry{set: make(map[string]struct{})}, false
+		m.data[key] = entry
+	}
+	for _, member := range members {
+		entry.set[member] = struct{}{}
+	}
+	// 与 Redis 实现一致：过期时间只延长不缩短，ttl<=0 时集合永不过期
+	expireAt := m.expireAt(ttl)
+	if !existed || expireAt.IsZero() || (!entry.expireAt.IsZero() && entry.expireAt.Before(expireAt)) {
+		entry.expireAt = expireAt
+	}
+	return nil
+}
+
+func (m *MemoryL2Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.load(key)
+	if !ok || entry.set == nil {
+		return nil, nil
+	}
+	members := make([]string, 0, len(entry.set))
+	for member := range entry.set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (m *MemoryL2Client) ScanPrefix(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	m.mu.Lock()
+	var keys []string
+	for key := range m.data {
+		if _, ok := m.load(key); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.Unlock()
+
+	// 回调中可能再次访问客户端，释放锁后再调用
+	for start := 0; start < len(keys); start += invalidateBatchSize {
+		end := min(start+invalidateBatchSize, len(keys))
+		if err := fn(keys[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryL2Client) Publish(ctx context.Context, channel, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for sub := range m.subscribers[channel] {
+		select {
+		case sub.ch <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *MemoryL2Client) Subscribe(ctx context.Context, channel string) L2Subscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub := &memorySubscription{
+		client:  m,
+		channel: channel,
+		ch:      make(chan string, memorySubscriptionBuffer),
+	}
+	if m.subscribers[channel] == nil {
+		m.subscribers[channel] = make(map[*memorySubscription]struct{})
+	}
+	m.subscribers[channel][sub] = struct{}{}
+	return sub
+}
+
+// Close 不做任何处理，共享该客户端的其他实例仍可继续使用
+func (m *MemoryL2Client) Close() error {
+	return nil
+}
+
+// memorySubscription 进程内频道订阅
+type memorySubscription struct {
+	client  *MemoryL2Client
+	channel string
+	ch      chan string
+	once    sync.Once
+}
+
+func (s *memorySubscription) Channel() <-chan string {
+	return s.ch
+}
+
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		s.client.mu.Lock()
+		defer s.client.mu.Unlock()
+		delete(s.client.subscribers[s.channel], s)
+		close(s.ch)
+	})
+	return nil
+}
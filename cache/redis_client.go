@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"zyj.com/golang-study/config"
+)
+
+// Redis 部署模式
+const (
+	RedisModeSingle   = "single"   // 单节点
+	RedisModeCluster  = "cluster"  // Redis Cluster
+	RedisModeSentinel = "sentinel" // 哨兵（主从自动切换）
+)
+
+// redisAddrs 返回二级缓存节点地址列表，兼容只配置了 RedisAddr 的旧配置
+func (c *Config) redisAddrs() []string {
+	if len(c.RedisAddrs) > 0 {
+		return c.RedisAddrs
+	}
+	if c.RedisAddr != "" {
+		return []string{c.RedisAddr}
+	}
+	return nil
+}
+
+// newRedisClient 按部署模式创建二级缓存客户端，未配置地址时使用 config.Init 加载的全局 Redis 配置
+// RedisMode 为空时按 redis.NewUniversalClient 的规则推断：配置了 MasterName 为哨兵，多个地址为集群，否则为单节点
+func newRedisClient(c *Config) (redis.UniversalClient, error) {
+	if len(c.redisAddrs()) == 0 {
+		if global := config.GetConfig(); global != nil {
+			c.ApplyRedisConfig(global.Redis)
+		}
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            c.redisAddrs(),
+		DB:               c.RedisDB,
+		Password:         c.RedisPassword,
+		SentinelPassword: c.RedisSentinelPassword,
+		MasterName:       c.RedisMasterName,
+		PoolSize:         c.RedisPoolSize,
+	}
+	if len(opts.Addrs) == 0 {
+		return nil, fmt.Errorf("redis address is empty")
+	}
+
+	switch c.RedisMode {
+	case "":
+		return redis.NewUniversalClient(opts), nil
+	case RedisModeSingle:
+		return redis.NewClient(opts.Simple()), nil
+	case RedisModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	case RedisModeSentinel:
+		if opts.MasterName == "" {
+			return nil, fmt.Errorf("redis sentinel mode requires master name")
+		}
+		return redis.NewFailoverClient(opts.Failover()), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode: %s", c.RedisMode)
+	}
+}
+
+// ApplyRedisConfig 使用全局 Redis 配置填充二级缓存连接参数
+func (c *Config) ApplyRedisConfig(rc *config.RedisConfig) {
+	if rc == nil {
+		return
+	}
+	c.RedisMode = rc.Mode
+	c.RedisAddrs = rc.GetAddrs()
+	c.RedisPassword = rc.Password
+	c.RedisSentinelPassword = rc.SentinelPassword
+	c.RedisMasterName = rc.MasterName
+	c.RedisDB = rc.DB
+	c.RedisPoolSize = rc.PoolSize
+}
+
+// redisL2Client 基于 Redis 的二级缓存客户端
+type redisL2Client struct {
+	client redis.UniversalClient
+}
+
+// NewRedisL2Client 包装 Redis 客户端（单节点/集群/哨兵）为二级缓存客户端
+func NewRedisL2Client(client redis.UniversalClient) L2Client {
+	return &redisL2Client{client: client}
+}
+
+func (r *redisL2Client) isCluster() bool {
+	_, ok := r.client.(*redis.ClusterClient)
+	return ok
+}
+
+func (r *redisL2Client) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *redisL2Client) Get(ctx context.Context, key string) (string, error) {
+	return r.client.Get(ctx, key).Result()
+}
+
+func (r *redisL2Client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// MGet 批量读取
+// 集群模式下多键 MGET 要求所有键位于同一槽位，改用管道逐键 GET，由客户端按节点分组发送
+func (r *redisL2Client) MGet(ctx context.Context, keys []string) ([]interface{}, error) {
+	if !r.isCluster() {
+		return r.client.MGet(ctx, keys...).Result()
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	// 管道的 Exec 返回第一个出错命令的错误，可能只是某个键不存在（redis.Nil），
+	// 因此逐个检查命令，返回第一个真正的错误
+	_, _ = pipe.Exec(ctx)
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		value, err := cmd.Result()
+		switch {
+		case err == nil:
+			values[i] = value
+		case err != redis.Nil:
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Del 分批删除
+// 逐键 DEL 而非一次多键 DEL，避免集群模式下跨槽位报错
+func (r *redisL2Client) Del(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += invalidateBatchSize {
+		end := min(start+invalidateBatchSize, len(keys))
+		pipe := r.client.Pipeline()
+		for _, key := range keys[start:end] {
+			pipe.Del(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saddScript 添加成员并只延长集合过期时间：新建的集合按 ttl 过期，已有集合仅在剩余时间短于 ttl 时延长，
+// 已经永不过期的集合保持不变；ttl<=0 表示成员永不过期，集合同样改为永不过期
+var saddScript = redis.NewScript(`
+local existed = redis.call('EXISTS', KEYS[1])
+redis.call('SADD', KEYS[1], unpack(ARGV, 2))
+local ttl = tonumber(ARGV[1])
+if ttl <= 0 then
+	redis.call('PERSIST', KEYS[1])
+	return 1
+end
+local current = redis.call('PTTL', KEYS[1])
+if existed == 0 or (current >= 0 and current < ttl) then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+func (r *redisL2Client) SAdd(ctx context.Context, key string, members []string, ttl time.Duration) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(members)+1)
+	args = append(args, ttl.Milliseconds())
+	for _, member := range members {
+		args = append(args, member)
+	}
+	return saddScript.Run(ctx, r.client, []string{key}, args...).Err()
+}
+
+func (r *redisL2Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
+
+// ScanPrefix 遍历以 prefix 开头的键，集群模式下在每个主节点上分别 SCAN
+func (r *redisL2Client) ScanPrefix(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	match := escapeGlob(prefix) + "*"
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, match, invalidateBatchSize).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(keys); err != nil {
+					return err
+				}
+			}
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	}
+
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	}
+	return scan(ctx, r.client)
+}
+
+func (r *redisL2Client) Publish(ctx context.Context, channel, message string) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe 订阅频道，集群模式下 PUBLISH 会广播到所有节点
+func (r *redisL2Client) Subscribe(ctx context.Context, channel string) L2Subscription {
+	pubSub := r.client.Subscribe(ctx, channel)
+	sub := &redisL2Subscription{pubSub: pubSub, ch: make(chan string), done: make(chan struct{})}
+	go sub.forward()
+	return sub
+}
+
+func (r *redisL2Client) Close() error {
+	return r.client.Close()
+}
+
+// redisL2Subscription 将 redis.Message 转换为消息内容
+type redisL2Subscription struct {
+	pubSub    *redis.PubSub
+	ch        chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *redisL2Subscription) forward() {
+	defer close(s.ch)
+	for msg := range s.pubSub.Channel() {
+		select {
+		case s.ch <- msg.Payload:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *redisL2Subscription) Channel() <-chan string {
+	return s.ch
+}
+
+// Close 可重复调用，只有第一次关闭订阅
+func (s *redisL2Subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.pubSub.Close()
+	})
+	return err
+}
+
+// escapeGlob 转义 Redis SCAN MATCH 模式中的特殊字符
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
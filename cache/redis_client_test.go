@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"zyj.com/golang-study/config"
+)
+
+func TestEscapeGlob(t *testing.T) {
+	cases := map[string]string{
+		"user:":       "user:",
+		"a*b?c":       `a\*b\?c`,
+		"[tag]":       `\[tag\]`,
+		`path\to`:     `path\\to`,
+		"用户:*":        `用户:\*`,
+		"":            "",
+		"plain-key_1": "plain-key_1",
+	}
+	for in, want := range cases {
+		if got := escapeGlob(in); got != want {
+			t.Fatalf("escapeGlob(%q): got %q want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewRedisClientSelectsMode(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   Config
+		cluster  bool
+		failover bool
+	}{
+		{"single addr", Config{RedisAddr: "127.0.0.1:6379"}, false, false},
+		{"inferred cluster", Config{RedisAddrs: []string{"127.0.0.1:7000", "127.0.0.1:7001"}}, true, false},
+		{"inferred sentinel", Config{RedisAddrs: []string{"127.0.0.1:26379"}, RedisMasterName: "mymaster"}, false, true},
+		{"explicit single", Config{RedisMode: RedisModeSingle, RedisAddrs: []string{"127.0.0.1:6379", "127.0.0.1:6380"}}, false, false},
+		{"explicit cluster", Config{RedisMode: RedisModeCluster, RedisAddr: "127.0.0.1:7000"}, true, false},
+		{"explicit sentinel", Config{RedisMode: RedisModeSentinel, RedisAddr: "127.0.0.1:26379", RedisMasterName: "mymaster"}, false, true},
+	}
+	for _, c := range cases {
+		client, err := newRedisClient(&c.config)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		_, isCluster := client.(*redis.ClusterClient)
+		isFailover := false
+		if single, ok := client.(*redis.Client); ok {
+			isFailover = single.Options().Addr == "FailoverClient"
+		}
+		client.Close()
+		if isCluster != c.cluster || isFailover != c.failover {
+			t.Fatalf("%s: cluster=%v failover=%v", c.name, isCluster, isFailover)
+		}
+	}
+}
+
+func TestNewRedisClientErrors(t *testing.T) {
+	cases := map[string]Config{
+		"empty address":           {},
+		"sentinel without master": {RedisMode: RedisModeSentinel, RedisAddr: "127.0.0.1:26379"},
+		"unknown mode":            {RedisMode: "ring", RedisAddr: "127.0.0.1:6379"},
+	}
+	for name, c := range cases {
+		if _, err := newRedisClient(&c); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNewRedisClientUsesGlobalConfig(t *testing.T) {
+	saved := config.GlobalConfig
+	defer func() { config.GlobalConfig = saved }()
+	config.GlobalConfig = &config.Config{Redis: &config.RedisConfig{
+		Mode:  RedisModeCluster,
+		Addrs: []string{"127.0.0.1:7000"},
+	}}
+
+	c := Config{}
+	client, err := newRedisClient(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		t.Fatalf("expected cluster client, got %T", client)
+	}
+	if c.RedisMode != RedisModeCluster || len(c.RedisAddrs) != 1 {
+		t.Fatalf("global redis config not applied: %+v", c)
+	}
+}
+
+func TestRedisSubscriptionCloseTwice(t *testing.T) {
+	client := NewRedisL2Client(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"}))
+	defer client.Close()
+	sub := client.Subscribe(context.Background(), "cache:sync")
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
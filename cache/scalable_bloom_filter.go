@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+const (
+	// 每次扩容时新层容量的增长倍数
+	defaultScalableGrowth = 2
+	// 每次扩容时新层误判率的收紧比例，保证总误判率收敛
+	defaultScalableTightening = 0.8
+)
+
+// scalableLayer 可扩展布隆过滤器的一层
+type scalableLayer struct {
+	filter   *bloom.BloomFilter
+	capacity uint
+	count    uint
+}
+
+// ScalableBloomFilter 可扩展布隆过滤器
+// 当前层写满后自动追加新层（容量按 growth 倍增，误判率按 tightening 收紧），
+// 元素数量超过预期时误判率不会无声地劣化
+type ScalableBloomFilter struct {
+	mu         sync.RWMutex
+	layers     []*scalableLayer
+	fpr        float64 // 下一层的误判率
+	growth     uint
+	tightening float64
+}
+
+// NewScalableBloomFilter 创建可扩展布隆过滤器
+// initialElements: 第一层预期元素数量
+// falsePositiveRate: 目标误判率
+func NewScalableBloomFilter(initialElements uint, falsePositiveRate float64) BloomFilter {
+	return NewScalableBloomFilterWithGrowth(initialElements, falsePositiveRate, defaultScalableGrowth, defaultScalableTightening)
+}
+
+// NewScalableBloomFilterWithGrowth 创建可扩展布隆过滤器并指定增长倍数和误判率收紧比例
+func NewScalableBloomFilterWithGrowth(initialElements uint, falsePositiveRate float64, growth uint, tightening float64) BloomFilter {
+	if initialElements == 0 {
+		initialElements = 1
+	}
+	if growth < 1 {
+		growth = defaultScalableGrowth
+	}
+	if tightening <= 0 || tightening >= 1 {
+		tightening = defaultScalableTightening
+	}
+	s := &ScalableBloomFilter{
+		// 各层误判率为 p*(1-r), p*(1-r)*r, ...，总和收敛于 p
+		fpr:        falsePositiveRate * (1 - tightening),
+		growth:     growth,
+		tightening: tightening,
+	}
+	s.addLayer(initialElements)
+	return s
+}
+
+// addLayer 追加新层，调用方需持有写锁
+func (s *ScalableBloomFilter) addLayer(capacity uint) {
+	s.layers = append(s.layers, &scalableLayer{
+		filter:   bloom.NewWithEstimates(capacity, s.fpr),
+		capacity: capacity,
+	})
+	s.fpr *= s.tightening
+}
+
+// add 添加单个元素，调用方需持有写锁
+func (s *ScalableBloomFilter) add(key string) {
+	// 已存在的元素不重复计数，避免过早扩容
+	for _, layer := range s.layers {
+		if layer.filter.TestString(key) {
+			return
+		}
+	}
+	current := s.layers[len(s.layers)-1]
+	if current.count >= current.capacity {
+		s.addLayer(current.capacity * s.growth)
+		current = s.layers[len(s.layers)-1]
+	}
+	current.filter.AddString(key)
+	current.count++
+}
+
+func (s *ScalableBloomFilter) Add(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.layers) == 0 {
+		return ErrBloomFilterClosed
+	}
+	s.add(key)
+	return nil
+}
+
+func (s *ScalableBloomFilter) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.layers) == 0 {
+		return false, ErrBloomFilterClosed
+	}
+	for _, layer := range s.layers {
+		if layer.filter.TestString(key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *ScalableBloomFilter) BatchAdd(ctx context.Context, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.layers) == 0 {
+		return ErrBloomFilterClosed
+	}
+	for _, key := range keys {
+		s.add(key)
+	}
+	return nil
+}
+
+// Stats 汇总各层统计，误判率为各层误判率的并：1-∏(1-p_i)
+func (s *ScalableBloomFilter) Stats() BloomFilterStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st := BloomFilterStats{Layers: len(s.layers)}
+	var setBits uint
+	notFalsePositive := 1.0
+	for _, layer := range s.layers {
+		layerSetBits := layer.filter.BitSet().Count()
+		st.Capacity += layer.capacity
+		st.Count += layer.count
+		st.Bits += layer.filter.Cap()
+		st.HashCount = layer.filter.K()
+		setBits += layerSetBits
+		notFalsePositive *= 1 - estimateFPR(float64(layerSetBits)/float64(layer.filter.Cap()), layer.filter.K())
+	}
+	if st.Bits > 0 {
+		st.FillRatio = float64(setBits) / float64(st.Bits)
+	}
+	st.EstimatedFPR = 1 - notFalsePositive
+	return st
+}
+
+func (s *ScalableBloomFilter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layers = nil
+	return nil
+}
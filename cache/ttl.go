@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TTLRule 按键前缀配置二级缓存过期时间
+type TTLRule struct {
+	Prefix string        `json:"prefix"`
+	TTL    time.Duration `json:"ttl"`
+}
+
+// sortTTLRules 按前缀长度降序排列，保证最长前缀优先匹配
+func sortTTLRules(rules []TTLRule) []TTLRule {
+	sorted := make([]TTLRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+	return sorted
+}
+
+// ttlFor 计算键的二级缓存过期时间
+// 优先级：调用方指定 > 前缀规则 > RedisTTL，最终叠加随机抖动，避免同一批写入同时过期（缓存雪崩）
+func (dc *DistributedCache) ttlFor(key string, ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		ttl = dc.config.RedisTTL
+		if ruleTTL, ok := matchTTLRule(dc.ttlRules, key); ok {
+			ttl = ruleTTL
+		}
+	}
+	return jitterTTL(ttl, dc.config.RedisTTLJitter)
+}
+
+// matchTTLRule 返回第一条匹配键前缀的规则过期时间，rules 需已按前缀长度降序排列
+func matchTTLRule(rules []TTLRule, key string) (time.Duration, bool) {
+	for _, rule := range rules {
+		if strings.HasPrefix(key, rule.Prefix) {
+			return rule.TTL, true
+		}
+	}
+	return 0, false
+}
+
+// l1TTL 计算键的一级缓存过期时间：OtterTTL 与前缀规则取较短者，不叠加抖动
+// 所有写入路径（Set、SetWithTags、加载器回填、刷新）都按该时间过期
+func l1TTL(otterTTL time.Duration, rules []TTLRule, key string) time.Duration {
+	ruleTTL, ok := matchTTLRule(rules, key)
+	if !ok || ruleTTL <= 0 || (otterTTL > 0 && otterTTL <= ruleTTL) {
+		return otterTTL
+	}
+	return ruleTTL
+}
+
+// negativeTTL 空值使用 NegativeTTL，其余返回 0 交由 ttlFor 按规则计算
+func (dc *DistributedCache) negativeTTL(value string) time.Duration {
+	if value == "" {
+		return dc.config.NegativeTTL
+	}
+	return 0
+}
+
+// jitterTTL 在 [ttl, ttl*(1+jitter)) 范围内随机取值，ttl<=0（永不过期）时不处理
+func jitterTTL(ttl time.Duration, jitter float64) time.Duration {
+	if ttl <= 0 || jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Float64()*jitter*float64(ttl))
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortTTLRulesLongestPrefixFirst(t *testing.T) {
+	rules := sortTTLRules([]TTLRule{
+		{Prefix: "user:", TTL: time.Minute},
+		{Prefix: "user:pk:", TTL: time.Second},
+		{Prefix: "item:", TTL: time.Hour},
+	})
+	// 前缀长度相同时保持配置顺序
+	want := []string{"user:pk:", "user:", "item:"}
+	for i, prefix := range want {
+		if rules[i].Prefix != prefix {
+			t.Fatalf("rules[%d]: got %q want %q", i, rules[i].Prefix, prefix)
+		}
+	}
+}
+
+func TestTTLFor(t *testing.T) {
+	dc := &DistributedCache{
+		config: Config{RedisTTL: time.Hour},
+		ttlRules: sortTTLRules([]TTLRule{
+			{Prefix: "user:", TTL: time.Minute},
+			{Prefix: "user:pk:", TTL: time.Second},
+		}),
+	}
+	cases := []struct {
+		key  string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{"user:pk:1", 0, time.Second},
+		{"user:name:a", 0, time.Minute},
+		{"order:1", 0, time.Hour},
+		{"user:pk:1", 5 * time.Second, 5 * time.Second},
+	}
+	for _, c := range cases {
+		if got := dc.ttlFor(c.key, c.ttl); got != c.want {
+			t.Fatalf("ttlFor(%q, %v): got %v want %v", c.key, c.ttl, got, c.want)
+		}
+	}
+}
+
+func TestJitterTTL(t *testing.T) {
+	ttl := time.Minute
+	for i := 0; i < 1000; i++ {
+		got := jitterTTL(ttl, 0.1)
+		if got < ttl || got >= ttl+6*time.Second {
+			t.Fatalf("jitter out of range: %v", got)
+		}
+	}
+	if got := jitterTTL(ttl, 0); got != ttl {
+		t.Fatalf("zero jitter: got %v", got)
+	}
+	if got := jitterTTL(0, 0.1); got != 0 {
+		t.Fatalf("no expiry should stay unchanged: got %v", got)
+	}
+}
+
+func TestL1TTL(t *testing.T) {
+	rules := sortTTLRules([]TTLRule{
+		{Prefix: "short:", TTL: time.Second},
+		{Prefix: "long:", TTL: time.Hour},
+	})
+	cases := []struct {
+		otterTTL time.Duration
+		key      string
+		want     time.Duration
+	}{
+		{time.Minute, "short:1", time.Second},
+		{time.Minute, "long:1", time.Minute},
+		{time.Minute, "other:1", time.Minute},
+		{0, "short:1", time.Second},
+	}
+	for _, c := range cases {
+		if got := l1TTL(c.otterTTL, rules, c.key); got != c.want {
+			t.Fatalf("l1TTL(%v, %q): got %v want %v", c.otterTTL, c.key, got, c.want)
+		}
+	}
+}
+
+func TestTTLRuleAppliesToL1OnSet(t *testing.T) {
+	client := NewMemoryL2Client()
+	dc, err := NewDistributedCacheWithClient(t.Context(), Config{
+		OtterMaxSize:  1000,
+		OtterTTL:      time.Minute,
+		RedisTTL:      time.Minute,
+		TTLRules:      []TTLRule{{Prefix: "short:", TTL: 30 * time.Millisecond}},
+		PubSubChannel: "cache:sync",
+		InstanceID:    "a",
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dc.Close()
+
+	dc.Set("short:1", "v")
+	dc.Set("other:1", "v")
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := dc.primaryCache.GetIfPresent("short:1"); ok {
+		t.Fatal("short:1 should have expired from L1")
+	}
+	if _, ok := dc.primaryCache.GetIfPresent("other:1"); !ok {
+		t.Fatal("other:1 should still be in L1")
+	}
+}
@@ -0,0 +1,393 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 写回模式默认配置
+const (
+	defaultWriteBehindFlushInterval = time.Second
+	defaultWriteBehindBatchSize     = 100
+	defaultWriteBehindMaxRetries    = 3
+	defaultWriteBehindRetryBackoff  = 100 * time.Millisecond
+)
+
+// Writer 写回模式下的后端存储，由使用方实现（如写数据库）
+type Writer interface {
+	// Write 批量写入，返回错误时整批重试
+	Write(ctx context.Context, entries map[string]string) error
+}
+
+// WriterFunc 函数适配为 Writer
+type WriterFunc func(ctx context.Context, entries map[string]string) error
+
+func (f WriterFunc) Write(ctx context.Context, entries map[string]string) error {
+	return f(ctx, entries)
+}
+
+// WriteBehindConfig 写回模式配置
+// 开启后 Set/SetWithTTL/SetWithTags 写完缓存即返回，后端存储的写入进入队列按键合并后异步批量刷新
+type WriteBehindConfig struct {
+	Enabled       bool          `json:"enabled"`
+	Writer        Writer        `json:"-"`
+	FlushInterval time.Duration `json:"flushInterval"` // 刷新间隔
+	BatchSize     int           `json:"batchSize"`     // 每批最多写入的键数量
+	MaxRetries    int           `json:"maxRetries"`    // 单批最大重试次数，耗尽后留在队列等待下次刷新；0 不重试，负数使用默认值
+	RetryBackoff  time.Duration `json:"retryBackoff"`  // 重试间隔，按次数线性增长
+	AOFPath       string        `json:"aofPath"`       // 本地追加日志路径，为空时队列不持久化
+	// 默认每次追加日志后 fsync，开启后只写入操作系统缓冲区：进程崩溃不丢数据，机器掉电可能丢失最近的写入
+	AOFNoSync bool `json:"aofNoSync"`
+}
+
+func (c *WriteBehindConfig) withDefaults() WriteBehindConfig {
+	cfg := *c
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultWriteBehindFlushInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultWriteBehindBatchSize
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = defaultWriteBehindMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultWriteBehindRetryBackoff
+	}
+	return cfg
+}
+
+// aofRecord 追加日志中的一条记录，Deleted 为墓碑记录，回放时丢弃该键之前的写入
+type aofRecord struct {
+	Key     string `json:"k"`
+	Value   string `json:"v,omitempty"`
+	Deleted bool   `json:"d,omitempty"`
+}
+
+// writeBehindQueue 按键合并的写回队列
+// 每次入队先追加到本地日志，刷新成功后用剩余队列重写日志，重启时回放日志恢复未写入的数据
+type writeBehindQueue struct {
+	config   WriteBehindConfig
+	mu       sync.Mutex
+	pending  map[string]string
+	inflight map[string]struct{} // 正在写入后端的键，被丢弃时移出，写入失败后不再放回队列
+	aof      *os.File
+	aofBuf   *bufio.Writer
+	flushing sync.Mutex // 保证同一时刻只有一个刷新
+	done     chan struct{}
+}
+
+func newWriteBehindQueue(config WriteBehindConfig) (*writeBehindQueue, error) {
+	cfg := config.withDefaults()
+	if cfg.Writer == nil {
+		return nil, fmt.Errorf("write-behind mode requires a writer")
+	}
+	q := &writeBehindQueue{
+		config:   cfg,
+		pending:  make(map[string]string),
+		inflight: make(map[string]struct{}),
+		done:     make(chan struct{}),
+	}
+	if cfg.AOFPath != "" {
+		if err := q.replay(); err != nil {
+			return nil, err
+		}
+		if err := q.rewriteAOF(); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// replay 回放本地日志，后写入的记录覆盖先写入的；末尾不完整的记录（写入中途退出）被忽略
+func (q *writeBehindQueue) replay() error {
+	file, err := os.Open(q.config.AOFPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var record aofRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("Skip corrupted write-behind record: %v", err)
+			continue
+		}
+		if record.Deleted {
+			delete(q.pending, record.Key)
+			continue
+		}
+		q.pending[record.Key] = record.Value
+	}
+	if len(q.pending) > 0 {
+		log.Printf("Recovered %d write-behind entries from %s", len(q.pending), q.config.AOFPath)
+	}
+	return scanner.Err()
+}
+
+// rewriteAOF 用当前队列内容重写本地日志，调用方需持有 mu 或保证无并发
+func (q *writeBehindQueue) rewriteAOF() error {
+	path := q.config.AOFPath
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for key, value := range q.pending {
+		if err := writeAOFRecord(w, aofRecord{Key: key, Value: value}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	if q.aof != nil {
+		q.aof.Close()
+	}
+	q.aof, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	q.aofBuf = bufio.NewWriter(q.aof)
+	return nil
+}
+
+func writeAOFRecord(w *bufio.Writer, record aofRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// enqueue 入队，同一个键只保留最新值
+func (q *writeBehindQueue) enqueue(key, value string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.appendAOF(aofRecord{Key: key, Value: value}); err != nil {
+		return err
+	}
+	q.pending[key] = value
+	return nil
+}
+
+// appendAOF 追加记录到本地日志，调用方需持有 mu
+func (q *writeBehindQueue) appendAOF(records ...aofRecord) error {
+	if q.aofBuf == nil || len(records) == 0 {
+		return nil
+	}
+	for _, record := range records {
+		if err := writeAOFRecord(q.aofBuf, record); err != nil {
+			return fmt.Errorf("failed to append write-behind log: %v", err)
+		}
+	}
+	if err := q.aofBuf.Flush(); err != nil {
+		return fmt.Errorf("failed to append write-behind log: %v", err)
+	}
+	if !q.config.AOFNoSync {
+		if err := q.aof.Sync(); err != nil {
+			return fmt.Errorf("failed to sync write-behind log: %v", err)
+		}
+	}
+	return nil
+}
+
+// discard 丢弃匹配的待写入键（包括正在写入的批次中写入失败后会放回队列的键），
+// 并在本地日志中追加墓碑记录，重启回放时不再恢复
+func (q *writeBehindQueue) discard(match func(key string) bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var tombstones []aofRecord
+	for key := range q.pending {
+		if match(key) {
+			delete(q.pending, key)
+			tombstones = append(tombstones, aofRecord{Key: key, Deleted: true})
+		}
+	}
+	for key := range q.inflight {
+		if match(key) {
+			delete(q.inflight, key)
+			tombstones = append(tombstones, aofRecord{Key: key, Deleted: true})
+		}
+	}
+	return q.appendAOF(tombstones...)
+}
+
+// takeBatch 从队列取出至多 BatchSize 个键
+func (q *writeBehindQueue) takeBatch() map[string]string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	batch := make(map[string]string, min(len(q.pending), q.config.BatchSize))
+	for key, value := range q.pending {
+		if len(batch) >= q.config.BatchSize {
+			break
+		}
+		batch[key] = value
+		delete(q.pending, key)
+		q.inflight[key] = struct{}{}
+	}
+	return batch
+}
+
+// release 批次写入成功，不再跟踪其中的键
+func (q *writeBehindQueue) release(batch map[string]string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for key := range batch {
+		delete(q.inflight, key)
+	}
+}
+
+// requeue 写入失败的键放回队列，期间已有更新值的键保留新值，期间被丢弃的键不再放回
+func (q *writeBehindQueue) requeue(batch map[string]string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for key, value := range batch {
+		if _, ok := q.inflight[key]; !ok {
+			continue
+		}
+		delete(q.inflight, key)
+		if _, ok := q.pending[key]; !ok {
+			q.pending[key] = value
+		}
+	}
+}
+
+// flush 分批写入后端存储，单批失败时按退避重试，重试耗尽则放回队列
+func (q *writeBehindQueue) flush(ctx context.Context) {
+	q.flushing.Lock()
+	defer q.flushing.Unlock()
+
+	flushed := false
+	for {
+		batch := q.takeBatch()
+		if len(batch) == 0 {
+			break
+		}
+		if err := q.writeWithRetry(ctx, batch); err != nil {
+			log.Printf("Failed to flush %d write-behind entries, will retry later: %v", len(batch), err)
+			q.requeue(batch)
+			break
+		}
+		q.release(batch)
+		flushed = true
+	}
+
+	if flushed && q.config.AOFPath != "" {
+		q.mu.Lock()
+		if err := q.rewriteAOF(); err != nil {
+			log.Printf("Failed to compact write-behind log: %v", err)
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *writeBehindQueue) writeWithRetry(ctx context.Context, batch map[string]string) error {
+	var err error
+	for attempt := 0; attempt <= q.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * q.config.RetryBackoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = q.config.Writer.Write(ctx, batch); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// run 定时刷新队列，直到 ctx 结束
+func (q *writeBehindQueue) run(ctx context.Context) {
+	defer close(q.done)
+	ticker := time.NewTicker(q.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.flush(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// close 等待后台刷新退出，做最后一次刷新并关闭本地日志
+// 最后一次刷新失败的数据保留在日志中，下次启动时恢复
+func (q *writeBehindQueue) close() error {
+	<-q.done
+	q.flush(context.Background())
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.aof == nil {
+		return nil
+	}
+	if err := q.aofBuf.Flush(); err != nil {
+		q.aof.Close()
+		return err
+	}
+	return q.aof.Close()
+}
+
+// PendingWrites 返回写回队列中尚未写入后端存储的键数量
+func (dc *DistributedCache) PendingWrites() int {
+	if dc.writeBehind == nil {
+		return 0
+	}
+	dc.writeBehind.mu.Lock()
+	defer dc.writeBehind.mu.Unlock()
+	return len(dc.writeBehind.pending)
+}
+
+// dropPendingWrites 丢弃写回队列中匹配的待写入键，未开启时不做处理
+// 删除或失效缓存后，之前排队的旧值不应再写入后端存储
+func (dc *DistributedCache) dropPendingWrites(match func(key string) bool) {
+	if dc.writeBehind == nil {
+		return
+	}
+	if err := dc.writeBehind.discard(match); err != nil {
+		log.Printf("Failed to drop pending write-behind entries: %v", err)
+	}
+}
+
+// writeBack 写回模式下将写入加入队列，未开启时不做处理
+func (dc *DistributedCache) writeBack(key, value string) error {
+	if dc.writeBehind == nil {
+		return nil
+	}
+	return dc.writeBehind.enqueue(key, value)
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingWriter 记录写入的数据，failures 次之前的写入返回错误
+type recordingWriter struct {
+	mu       sync.Mutex
+	written  map[string]string
+	calls    int
+	failures int
+}
+
+func newRecordingWriter(failures int) *recordingWriter {
+	return &recordingWriter{written: make(map[string]string), failures: failures}
+}
+
+func (w *recordingWriter) Write(ctx context.Context, entries map[string]string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls++
+	if w.calls <= w.failures {
+		return errors.New("backend unavailable")
+	}
+	for key, value := range entries {
+		w.written[key] = value
+	}
+	return nil
+}
+
+func (w *recordingWriter) get(key string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	value, ok := w.written[key]
+	return value, ok
+}
+
+func newTestQueue(t *testing.T, writer Writer, aofPath string) *writeBehindQueue {
+	t.Helper()
+	q, err := newWriteBehindQueue(WriteBehindConfig{
+		Enabled:      true,
+		Writer:       writer,
+		BatchSize:    2,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		AOFPath:      aofPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestWriteBehindQueueCoalesceAndFlush(t *testing.T) {
+	writer := newRecordingWriter(0)
+	q := newTestQueue(t, writer, "")
+	q.enqueue("a", "1")
+	q.enqueue("a", "2")
+	q.enqueue("b", "1")
+	q.enqueue("c", "1")
+	if len(q.pending) != 3 {
+		t.Fatalf("pending = %v, want keys coalesced", q.pending)
+	}
+
+	q.flush(context.Background())
+	// BatchSize 为 2，三个键分两批写入
+	if writer.calls != 2 {
+		t.Fatalf("writer calls = %d, want 2", writer.calls)
+	}
+	if value, _ := writer.get("a"); value != "2" {
+		t.Fatalf("a = %q, want latest value", value)
+	}
+	if len(q.pending) != 0 || len(q.inflight) != 0 {
+		t.Fatalf("queue not drained: %v %v", q.pending, q.inflight)
+	}
+}
+
+func TestWriteBehindQueueRetry(t *testing.T) {
+	// 前两次失败，第三次（第二次重试）成功
+	writer := newRecordingWriter(2)
+	q := newTestQueue(t, writer, "")
+	q.enqueue("a", "1")
+	q.flush(context.Background())
+	if writer.calls != 3 {
+		t.Fatalf("writer calls = %d, want 3", writer.calls)
+	}
+	if _, ok := writer.get("a"); !ok || len(q.pending) != 0 {
+		t.Fatal("entry not written after retries")
+	}
+
+	// 重试耗尽后放回队列，期间写入的新值保留
+	writer = newRecordingWriter(100)
+	q = newTestQueue(t, writer, "")
+	q.enqueue("a", "1")
+	q.config.Writer = WriterFunc(func(ctx context.Context, entries map[string]string) error {
+		q.enqueue("a", "2")
+		return writer.Write(ctx, entries)
+	})
+	q.flush(context.Background())
+	if q.pending["a"] != "2" {
+		t.Fatalf("pending = %v, want newer value kept after failed flush", q.pending)
+	}
+}
+
+func TestWriteBehindQueueReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "write_behind.aof")
+	q := newTestQueue(t, newRecordingWriter(0), path)
+	q.enqueue("a", "1")
+	q.enqueue("a", "2")
+	q.enqueue("b", "1")
+	q.enqueue("c", "1")
+	q.discard(func(key string) bool { return key == "b" })
+	// 模拟进程退出：不刷新，直接关闭日志
+	q.aof.Close()
+
+	restored := newTestQueue(t, newRecordingWriter(0), path)
+	want := map[string]string{"a": "2", "c": "1"}
+	if len(restored.pending) != len(want) {
+		t.Fatalf("replayed %v, want %v", restored.pending, want)
+	}
+	for key, value := range want {
+		if restored.pending[key] != value {
+			t.Fatalf("replayed %v, want %v", restored.pending, want)
+		}
+	}
+	restored.aof.Close()
+}
+
+func TestWriteBehindQueueDiscardInflight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "write_behind.aof")
+	writer := newRecordingWriter(100)
+	q := newTestQueue(t, writer, path)
+	q.enqueue("a", "1")
+	q.enqueue("b", "1")
+	// 写入过程中 a 被删除，写入失败后 a 不再放回队列
+	q.config.Writer = WriterFunc(func(ctx context.Context, entries map[string]string) error {
+		q.discard(func(key string) bool { return key == "a" })
+		return writer.Write(ctx, entries)
+	})
+	q.flush(context.Background())
+	if _, ok := q.pending["a"]; ok || q.pending["b"] != "1" {
+		t.Fatalf("pending = %v, want only b requeued", q.pending)
+	}
+	q.aof.Close()
+
+	restored := newTestQueue(t, newRecordingWriter(0), path)
+	if _, ok := restored.pending["a"]; ok || restored.pending["b"] != "1" {
+		t.Fatalf("replayed %v, want only b", restored.pending)
+	}
+	restored.aof.Close()
+}
+
+func newWriteBehindCache(t *testing.T, writer Writer, aofPath string) *DistributedCache {
+	t.Helper()
+	dc, err := NewDistributedCacheWithClient(context.Background(), Config{
+		OtterMaxSize:  1000,
+		OtterTTL:      time.Minute,
+		RedisTTL:      time.Minute,
+		PubSubChannel: "cache:sync",
+		InstanceID:    "a",
+		WriteBehind: WriteBehindConfig{
+			Enabled:       true,
+			Writer:        writer,
+			FlushInterval: time.Hour,
+			MaxRetries:    1,
+			RetryBackoff:  time.Millisecond,
+			AOFPath:       aofPath,
+		},
+	}, NewMemoryL2Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dc
+}
+
+func TestWriteBehindFlushOnClose(t *testing.T) {
+	writer := newRecordingWriter(0)
+	dc := newWriteBehindCache(t, writer, filepath.Join(t.TempDir(), "write_behind.aof"))
+	dc.Set("k", "v")
+	if dc.PendingWrites() != 1 {
+		t.Fatalf("pending = %d", dc.PendingWrites())
+	}
+	dc.Close()
+	if value, _ := writer.get("k"); value != "v" {
+		t.Fatalf("k = %q, want flushed on Close", value)
+	}
+}
+
+func TestWriteBehindCloseFailureRecoveredOnRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "write_behind.aof")
+	dc := newWriteBehindCache(t, newRecordingWriter(100), path)
+	dc.Set("k", "v")
+	// 关闭时写入失败，数据留在日志中
+	dc.Close()
+
+	writer := newRecordingWriter(0)
+	restarted := newWriteBehindCache(t, writer, path)
+	if restarted.PendingWrites() != 1 {
+		t.Fatalf("pending after restart = %d, want 1", restarted.PendingWrites())
+	}
+	restarted.Close()
+	if value, _ := writer.get("k"); value != "v" {
+		t.Fatalf("k = %q, want recovered and flushed", value)
+	}
+}
+
+func TestWriteBehindDropOnInvalidate(t *testing.T) {
+	writer := newRecordingWriter(0)
+	dc := newWriteBehindCache(t, writer, filepath.Join(t.TempDir(), "write_behind.aof"))
+	dc.Set("deleted", "v")
+	dc.SetWithTags("tagged", "v", "t")
+	dc.Set("prefix:1", "v")
+	dc.Set("kept", "v")
+
+	dc.Delete("deleted")
+	dc.InvalidateTag("t")
+	dc.InvalidatePrefix("prefix:")
+	if dc.PendingWrites() != 1 {
+		t.Fatalf("pending = %d, want only kept", dc.PendingWrites())
+	}
+	dc.Close()
+	for _, key := range []string{"deleted", "tagged", "prefix:1"} {
+		if _, ok := writer.get(key); ok {
+			t.Fatalf("%s written after invalidation", key)
+		}
+	}
+	if _, ok := writer.get("kept"); !ok {
+		t.Fatal("kept not written")
+	}
+}
+
+func TestWriteBehindMaxRetries(t *testing.T) {
+	cases := map[int]int{0: 1, 2: 3, -1: defaultWriteBehindMaxRetries + 1}
+	for maxRetries, wantCalls := range cases {
+		writer := newRecordingWriter(100)
+		q, err := newWriteBehindQueue(WriteBehindConfig{
+			Enabled:      true,
+			Writer:       writer,
+			MaxRetries:   maxRetries,
+			RetryBackoff: time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.enqueue("a", "1")
+		q.flush(context.Background())
+		// MaxRetries 为 0 时不重试，负数使用默认值
+		if writer.calls != wantCalls {
+			t.Fatalf("MaxRetries %d: writer calls = %d, want %d", maxRetries, writer.calls, wantCalls)
+		}
+	}
+}
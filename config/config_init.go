@@ -7,6 +7,8 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"strings"
+	"zyj.com/golang-study/api"
+	"zyj.com/golang-study/tslog"
 )
 
 var (
@@ -20,6 +22,7 @@ type Config struct {
 	Server   *ServerConfig   `mapstructure:"server"`
 	Database *DatabaseConfig `mapstructure:"database"`
 	Redis    *RedisConfig    `mapstructure:"redis"`
+	Log      *LogConfig      `mapstructure:"log"`
 }
 
 // 初始化配置
@@ -54,6 +57,10 @@ func Init() error {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 	GlobalConfig = cfg
+	// 首次加载时同样应用日志级别，之后由配置监听在变更时重新应用
+	applyLogConfig(cfg.Log)
+	// 生产环境响应中不返回调用栈和内部错误详情
+	api.SetProductionMode(isProduction(*env))
 	// 设置配置文件监听
 	setupConfigWatch(viper_, cfg)
 	log.Printf("Config loaded successfully from: %s", v.ConfigFileUsed())
@@ -82,6 +89,30 @@ func triggerConfigUpdate(cfg *Config) {
 	// 例如：重新连接数据库、更新日志级别等
 	log.Printf("Configuration updated - App: %s, Debug: %t",
 		cfg.Server.Name, cfg.Server.Debug)
+	applyLogConfig(cfg.Log)
+}
+
+// 应用日志级别配置，无需重启即可生效
+func applyLogConfig(logCfg *LogConfig) {
+	if logCfg == nil {
+		return
+	}
+	if logCfg.Level != "" {
+		if err := tslog.SetLevel(logCfg.Level); err != nil {
+			log.Printf("Failed to update log level: %v", err)
+		}
+	}
+	// 配置中已移除的命名日志器恢复跟随全局级别
+	for name := range tslog.NamedLevels() {
+		if _, ok := logCfg.Levels[name]; !ok {
+			tslog.SetNamedLevel(name, "")
+		}
+	}
+	for name, level := range logCfg.Levels {
+		if err := tslog.SetNamedLevel(name, level); err != nil {
+			log.Printf("Failed to update log level of %s: %v", name, err)
+		}
+	}
 }
 
 // 触发配置更新事件
@@ -107,6 +138,11 @@ func Reload() error {
 	return viper.Unmarshal(GlobalConfig)
 }
 
+// 是否为生产环境
+func isProduction(env string) bool {
+	return env == "prod" || env == "production"
+}
+
 // 根据环境获取配置文件名
 func GetConfigFileByEnv(env string) string {
 	switch env {
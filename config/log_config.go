@@ -0,0 +1,6 @@
+package config
+
+type LogConfig struct {
+	Level  string            `mapstructure:"level"`  // 全局日志级别: debug, info, warn, error
+	Levels map[string]string `mapstructure:"levels"` // 按日志器名称覆盖的级别
+}
@@ -3,13 +3,28 @@ package config
 import "fmt"
 
 type RedisConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"pool_size"`
+	Mode             string   `mapstructure:"mode"` // single/cluster/sentinel，为空时自动推断
+	Host             string   `mapstructure:"host"`
+	Port             int      `mapstructure:"port"`
+	Addrs            []string `mapstructure:"addrs"` // 集群种子节点或哨兵节点地址，配置后忽略 host/port
+	MasterName       string   `mapstructure:"master_name"`
+	Password         string   `mapstructure:"password"`
+	SentinelPassword string   `mapstructure:"sentinel_password"`
+	DB               int      `mapstructure:"db"`
+	PoolSize         int      `mapstructure:"pool_size"`
 }
 
 func (r *RedisConfig) GetAddr() string {
 	return fmt.Sprintf("%s:%d", r.Host, r.Port)
 }
+
+// GetAddrs 获取节点地址列表，未配置 addrs 时使用 host:port
+func (r *RedisConfig) GetAddrs() []string {
+	if len(r.Addrs) > 0 {
+		return r.Addrs
+	}
+	if r.Host == "" {
+		return nil
+	}
+	return []string{r.GetAddr()}
+}
@@ -7,7 +7,10 @@ const (
 	APP_VERSION_KEY = "App-Version"
 	AppPlatform     = "Platform"
 
-	HEADER_TRACE_ID_KEY = "X-Request-Id"
+	HEADER_TRACE_ID_KEY  = "X-Request-Id"
+	HEADER_SPAN_ID_KEY   = "X-Span-Id"
+	HEADER_USER_ID_KEY   = "User-ID"
+	HEADER_TENANT_ID_KEY = "X-Tenant-Id"
 
 	App_ID_KEY = "app_id"
 
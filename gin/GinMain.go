@@ -7,26 +7,16 @@ import (
 	"net/http"
 	"time"
 	t "zyj.com/golang-study/gin/test"
+	"zyj.com/golang-study/tslog"
 )
 
 func main() {
-	router := gin.Default()
+	// 退出前写出异步缓冲中的日志
+	defer tslog.Sync()
+	router := gin.New()
 	gin.ForceConsoleColor()
-	//gin中间件拦截器日志格式
-	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// 你的自定义格式
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
-	}))
+	//gin中间件拦截器日志：每个请求一条结构化访问日志
+	router.Use(tslog.GinAccessLog(), gin.Recovery())
 
 	//路由日志格式内容
 	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
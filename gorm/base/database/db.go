@@ -44,7 +44,7 @@ func Init(driver, dsn string) error {
 			DontSupportRenameIndex:    true,  // 重命名索引时采用删除并新建的方式，MySQL 5.7 之前的数据库和 MariaDB 不支持重命名索引
 			DontSupportRenameColumn:   true,  // 用 `change` 重命名列，MySQL 8 之前的数据库和 MariaDB 不支持重命名列
 			SkipInitializeWithVersion: false, // 根据当前 MySQL 版本自动配置
-		}), &gorm.Config{Logger: tslog.New(tslog.Logger)})
+		}), &gorm.Config{Logger: tslog.NewGormLogger(tslog.Default())})
 
 		dbEngine = db
 		if err != nil {
@@ -0,0 +1,158 @@
+package tserror
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"zyj.com/golang-study/define"
+)
+
+// 原 define 中的响应码，统一纳入 RespCode
+const (
+	CodeTokenEmpty       RespCode = define.TOKEN_EMPTY          // token 为空
+	CodeTokenParseError  RespCode = define.TOKEN_PARSE_ERROR    // token 解析失败
+	CodeTokenNotValid    RespCode = define.TOKEN_NOT_VALID      // token 无效
+	CodeTokenInfoInvalid RespCode = define.TOKEN_INFO_NOT_VALID // token 信息无效
+	CodeTokenUserError   RespCode = define.TOKEN_USER_ERROR     // token 用户错误
+	CodeProjectNone      RespCode = define.PROJECT_NONE         // 项目不存在
+	CodeProjectNoAuth    RespCode = define.PROJECT_NO_AUTH      // 无项目权限
+	CodePasswordError    RespCode = define.PASSWORD_ERROR       // 密码错误
+	CodeNoEnoughSpace    RespCode = define.NO_ENOUGH_SPACE      // 空间不足
+	CodeRetouchError     RespCode = define.RETHOUCH_ERROR       // 修图失败
+	CodePanic            RespCode = define.ERROR_PANIC          // 服务异常
+)
+
+// CodeInfo 错误码注册信息
+type CodeInfo struct {
+	Code         RespCode
+	HttpStatus   int            // 对应的 HTTP 状态码，0 表示未指定
+	Message      string         // 默认消息（简体中文）
+	Translations map[int]string // 其他语言的消息，key 为 define.LanguageXxx
+}
+
+var (
+	codeMu       sync.RWMutex
+	codeRegistry = make(map[RespCode]CodeInfo)
+)
+
+// unknownMessages 未注册错误码的消息
+var unknownMessages = map[int]string{
+	define.LanguageSimplifiedChinese:  "未知错误",
+	define.LanguageTraditionalChinese: "未知錯誤",
+	define.LanguageEnglish:            "Unknown error",
+}
+
+func init() {
+	Register(
+		CodeInfo{Code: CodeSuccess, HttpStatus: http.StatusOK, Message: "成功",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "成功", define.LanguageEnglish: "Success"}},
+
+		// 通用错误码
+		CodeInfo{Code: ClientError, HttpStatus: http.StatusBadRequest, Message: "请求错误",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "請求錯誤", define.LanguageEnglish: "Bad request"}},
+		CodeInfo{Code: CodeParamInvalid, HttpStatus: http.StatusBadRequest, Message: "参数验证失败",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "參數驗證失敗", define.LanguageEnglish: "Invalid parameters"}},
+		CodeInfo{Code: CodeParamBind, HttpStatus: http.StatusBadRequest, Message: "参数绑定失败",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "參數綁定失敗", define.LanguageEnglish: "Failed to bind parameters"}},
+		CodeInfo{Code: CodeUnauthorized, HttpStatus: http.StatusUnauthorized, Message: "未授权",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "未授權", define.LanguageEnglish: "Unauthorized"}},
+		CodeInfo{Code: CodePermissionDenied, HttpStatus: http.StatusForbidden, Message: "权限不足",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "權限不足", define.LanguageEnglish: "Permission denied"}},
+		CodeInfo{Code: CodeTooManyRequests, HttpStatus: http.StatusTooManyRequests, Message: "请求过于频繁，请稍后重试",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "請求過於頻繁，請稍後重試", define.LanguageEnglish: "Too many requests, please try again later"}},
+
+		// 用户模块
+		CodeInfo{Code: CodeUserNotFound, HttpStatus: http.StatusNotFound, Message: "用户不存在",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶不存在", define.LanguageEnglish: "User not found"}},
+		CodeInfo{Code: CodeUserExist, HttpStatus: http.StatusConflict, Message: "用户已存在",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶已存在", define.LanguageEnglish: "User already exists"}},
+
+		// 系统错误码
+		CodeInfo{Code: CodeServerInternalError, HttpStatus: http.StatusInternalServerError, Message: "系统内部错误",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "系統內部錯誤", define.LanguageEnglish: "Internal server error"}},
+		CodeInfo{Code: CodeDatabaseError, HttpStatus: http.StatusInternalServerError, Message: "数据库错误",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "資料庫錯誤", define.LanguageEnglish: "Database error"}},
+		CodeInfo{Code: CodeExternalServiceError, HttpStatus: http.StatusBadGateway, Message: "外部服务错误",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "外部服務錯誤", define.LanguageEnglish: "External service error"}},
+		CodeInfo{Code: CodeServiceUnavailable, HttpStatus: http.StatusServiceUnavailable, Message: "服务暂不可用，请稍后重试",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "服務暫不可用，請稍後重試", define.LanguageEnglish: "Service unavailable, please try again later"}},
+
+		// 原 define 中的响应码
+		CodeInfo{Code: CodeTokenEmpty, HttpStatus: http.StatusUnauthorized, Message: define.TokenErrorMessage,
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶信息過期，請重新登錄", define.LanguageEnglish: "Session expired, please log in again"}},
+		CodeInfo{Code: CodeTokenParseError, HttpStatus: http.StatusUnauthorized, Message: define.TokenErrorMessage,
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶信息過期，請重新登錄", define.LanguageEnglish: "Session expired, please log in again"}},
+		CodeInfo{Code: CodeTokenNotValid, HttpStatus: http.StatusUnauthorized, Message: define.TokenErrorMessage,
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶信息過期，請重新登錄", define.LanguageEnglish: "Session expired, please log in again"}},
+		CodeInfo{Code: CodeTokenInfoInvalid, HttpStatus: http.StatusUnauthorized, Message: define.TokenErrorMessage,
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶信息過期，請重新登錄", define.LanguageEnglish: "Session expired, please log in again"}},
+		CodeInfo{Code: CodeTokenUserError, HttpStatus: http.StatusUnauthorized, Message: define.TokenErrorMessage,
+			Translations: map[int]string{define.LanguageTraditionalChinese: "用戶信息過期，請重新登錄", define.LanguageEnglish: "Session expired, please log in again"}},
+		CodeInfo{Code: CodeProjectNone, HttpStatus: http.StatusNotFound, Message: "项目不存在",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "項目不存在", define.LanguageEnglish: "Project not found"}},
+		CodeInfo{Code: CodeProjectNoAuth, HttpStatus: http.StatusForbidden, Message: "无项目权限",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "無項目權限", define.LanguageEnglish: "No permission for this project"}},
+		CodeInfo{Code: CodePasswordError, HttpStatus: http.StatusBadRequest, Message: "密码错误",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "密碼錯誤", define.LanguageEnglish: "Incorrect password"}},
+		CodeInfo{Code: CodeNoEnoughSpace, HttpStatus: http.StatusBadRequest, Message: "空间不足",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "空間不足", define.LanguageEnglish: "Not enough space"}},
+		CodeInfo{Code: CodeRetouchError, HttpStatus: http.StatusInternalServerError, Message: "修图失败",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "修圖失敗", define.LanguageEnglish: "Retouch failed"}},
+		CodeInfo{Code: CodePanic, HttpStatus: http.StatusInternalServerError, Message: "服务异常",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "服務異常", define.LanguageEnglish: "Service error"}},
+	)
+}
+
+// Register 注册错误码，各模块在 init 中调用，重复注册同一错误码会 panic
+func Register(infos ...CodeInfo) {
+	codeMu.Lock()
+	defer codeMu.Unlock()
+	for _, info := range infos {
+		if _, exists := codeRegistry[info.Code]; exists {
+			panic(fmt.Sprintf("tserror: code %d registered twice", info.Code))
+		}
+		codeRegistry[info.Code] = info
+	}
+}
+
+// Lookup 获取错误码的注册信息
+func Lookup(code RespCode) (CodeInfo, bool) {
+	codeMu.RLock()
+	defer codeMu.RUnlock()
+	info, ok := codeRegistry[code]
+	return info, ok
+}
+
+// RegisteredCodes 获取所有已注册的错误码，按错误码排序
+func RegisteredCodes() []CodeInfo {
+	codeMu.RLock()
+	infos := make([]CodeInfo, 0, len(codeRegistry))
+	for _, info := range codeRegistry {
+		infos = append(infos, info)
+	}
+	codeMu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// Message 获取错误码在指定语言下的消息，没有对应翻译时使用默认消息
+func Message(code RespCode, lang int) string {
+	info, ok := Lookup(code)
+	if !ok {
+		if msg, exists := unknownMessages[lang]; exists {
+			return msg
+		}
+		return unknownMessages[define.LanguageSimplifiedChinese]
+	}
+	if msg, exists := info.Translations[lang]; exists && msg != "" {
+		return msg
+	}
+	return info.Message
+}
+
+// GetErrorMessage 获取错误消息
+func GetErrorMessage(code RespCode) string {
+	return Message(code, define.LanguageSimplifiedChinese)
+}
@@ -0,0 +1,87 @@
+package tserror
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"zyj.com/golang-study/define"
+)
+
+// unregister 测试结束后移除注册的错误码
+func unregister(t *testing.T, codes ...RespCode) {
+	t.Cleanup(func() {
+		codeMu.Lock()
+		defer codeMu.Unlock()
+		for _, code := range codes {
+			delete(codeRegistry, code)
+		}
+	})
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	const code RespCode = 99001
+	unregister(t, code)
+	Register(CodeInfo{Code: code, HttpStatus: http.StatusConflict, Message: "订单已支付",
+		Translations: map[int]string{define.LanguageEnglish: "Order already paid"}})
+
+	info, ok := Lookup(code)
+	if !ok || info.HttpStatus != http.StatusConflict {
+		t.Fatalf("Lookup = %+v, %v", info, ok)
+	}
+	codes := RegisteredCodes()
+	for i := 1; i < len(codes); i++ {
+		if codes[i-1].Code >= codes[i].Code {
+			t.Fatalf("codes not sorted at %d: %d >= %d", i, codes[i-1].Code, codes[i].Code)
+		}
+	}
+	if codes[len(codes)-1].Code != code {
+		t.Fatalf("registered code missing from RegisteredCodes")
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "registered twice") {
+			t.Fatalf("recover = %v", r)
+		}
+	}()
+	Register(CodeInfo{Code: CodeParamInvalid, Message: "dup"})
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		code RespCode
+		lang int
+		want string
+	}{
+		{CodeUserNotFound, define.LanguageSimplifiedChinese, "用户不存在"},
+		{CodeUserNotFound, define.LanguageTraditionalChinese, "用戶不存在"},
+		{CodeUserNotFound, define.LanguageEnglish, "User not found"},
+		// 未知语言使用默认消息
+		{CodeUserNotFound, 99, "用户不存在"},
+		{CodeTokenEmpty, define.LanguageSimplifiedChinese, define.TokenErrorMessage},
+		// 未注册的错误码
+		{99999, define.LanguageEnglish, "Unknown error"},
+		{99999, 99, "未知错误"},
+	}
+	for _, tt := range tests {
+		if got := Message(tt.code, tt.lang); got != tt.want {
+			t.Errorf("Message(%d, %d) = %q, want %q", tt.code, tt.lang, got, tt.want)
+		}
+	}
+	if GetErrorMessage(CodeParamBind) != "参数绑定失败" {
+		t.Fatalf("GetErrorMessage = %q", GetErrorMessage(CodeParamBind))
+	}
+}
+
+func TestMessageMissingTranslation(t *testing.T) {
+	const code RespCode = 99002
+	unregister(t, code)
+	Register(CodeInfo{Code: code, Message: "仅中文", Translations: map[int]string{define.LanguageEnglish: ""}})
+	// 翻译为空时使用默认消息
+	if got := Message(code, define.LanguageEnglish); got != "仅中文" {
+		t.Fatalf("Message = %q", got)
+	}
+}
@@ -0,0 +1,310 @@
+package tserror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+const maxStackDepth = 32
+
+// Error 统一错误类型：错误码、原因链、结构化字段、调用栈、是否可重试和 HTTP 状态
+// 支持 errors.Is/As 和 errors.Join，errors.As 也能将其转换为 BizError 或 SystemError
+type Error struct {
+	Code       RespCode
+	Message    string // 用户友好错误信息
+	HttpStatus int    // 为 0 时使用错误码注册的 HTTP 状态
+	Retryable  bool   // 调用方是否可以重试
+	fields     []interface{}
+	cause      error
+	stack      []uintptr
+}
+
+// New 创建统一错误并捕获调用栈，message 为空时使用注册的默认消息
+func New(code RespCode, message string) *Error {
+	return newError(code, message, nil)
+}
+
+// Wrap 以指定错误码包装原始错误，原因链中已有调用栈时不再重复捕获
+func Wrap(err error, code RespCode, message string) *Error {
+	return newError(code, message, err)
+}
+
+// Join 合并多个错误后以指定错误码包装，errors.Is/As 可以匹配其中任意一个
+func Join(code RespCode, message string, errs ...error) *Error {
+	return newError(code, message, errors.Join(errs...))
+}
+
+func newError(code RespCode, message string, cause error) *Error {
+	if message == "" {
+		message = GetErrorMessage(code)
+	}
+	e := &Error{Code: code, Message: message, cause: cause}
+	if !hasStack(cause) {
+		pcs := make([]uintptr, maxStackDepth)
+		e.stack = pcs[:runtime.Callers(3, pcs)]
+	}
+	return e
+}
+
+// With 附加键值对字段，如 With("user_id", 1, "table", "user")
+func (e *Error) With(kv ...interface{}) *Error {
+	e.fields = append(e.fields, kv...)
+	return e
+}
+
+// WithRetryable 标记错误是否可以重试
+func (e *Error) WithRetryable(retryable bool) *Error {
+	e.Retryable = retryable
+	return e
+}
+
+// WithHttpStatus 指定 HTTP 状态，优先于错误码注册的状态
+func (e *Error) WithHttpStatus(status int) *Error {
+	e.HttpStatus = status
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同即视为同一错误，如 errors.Is(err, tserror.New(tserror.CodeUserNotFound, ""))
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// As 兼容原有的 BizError 和 SystemError：HTTP 状态小于 500 时可转换为 BizError，否则为 SystemError
+func (e *Error) As(target interface{}) bool {
+	switch t := target.(type) {
+	case **BizError:
+		if e.status() >= http.StatusInternalServerError {
+			return false
+		}
+		*t = &BizError{Code: e.Code, Message: e.Message}
+		return true
+	case **SystemError:
+		if e.status() < http.StatusInternalServerError {
+			return false
+		}
+		sysErr := &SystemError{Code: e.Code, Message: e.Message, Original: e.cause}
+		if e.cause != nil {
+			sysErr.Detail = e.cause.Error()
+		}
+		*t = sysErr
+		return true
+	}
+	return false
+}
+
+// Fields 当前错误附加的字段
+func (e *Error) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(e.fields)/2)
+	for i := 0; i < len(e.fields); i += 2 {
+		key := fmt.Sprint(e.fields[i])
+		if i+1 < len(e.fields) {
+			fields[key] = e.fields[i+1]
+		} else {
+			fields[key] = nil
+		}
+	}
+	return fields
+}
+
+// StackTrace 创建错误时的调用栈，每行一个 函数 文件:行号
+func (e *Error) StackTrace() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+func (e *Error) status() int {
+	if e.HttpStatus != 0 {
+		return e.HttpStatus
+	}
+	return codeHttpStatus(e.Code)
+}
+
+// Format %+v 输出错误信息、字段、调用栈及原因链中的详细信息
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, e.Error())
+			e.formatDetail(s)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+func (e *Error) formatDetail(w io.Writer) {
+	if len(e.fields) > 0 {
+		fmt.Fprintf(w, "\nfields: %v", e.Fields())
+	}
+	if stack := e.StackTrace(); stack != "" {
+		io.WriteString(w, "\n"+stack)
+	}
+	var next *Error
+	if errors.As(e.cause, &next) {
+		next.formatDetail(w)
+	} else if _, ok := e.cause.(fmt.Formatter); ok && len(e.stack) == 0 {
+		// 原因链中的 pkg/errors 等错误自带调用栈
+		fmt.Fprintf(w, "\ncaused by: %+v", e.cause)
+	}
+}
+
+// hasStack 原因链中是否已经有调用栈
+func hasStack(err error) bool {
+	if err == nil {
+		return false
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return true
+	}
+	var tracer interface{ StackTrace() pkgerrors.StackTrace }
+	return errors.As(err, &tracer)
+}
+
+// CodeOf 获取错误码，兼容 BizError 和 SystemError，无法识别时返回 CodeServerInternalError
+func CodeOf(err error) RespCode {
+	if err == nil {
+		return CodeSuccess
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	var bizErr *BizError
+	if errors.As(err, &bizErr) {
+		return bizErr.Code
+	}
+	var sysErr *SystemError
+	if errors.As(err, &sysErr) {
+		return sysErr.Code
+	}
+	return CodeServerInternalError
+}
+
+// HttpStatusOf 获取错误对应的 HTTP 状态：显式指定的状态优先，其次按错误码映射，
+// BizError 不会映射为 5xx，无法识别的错误为 500
+func HttpStatusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.status()
+	}
+	var bizErr *BizError
+	if errors.As(err, &bizErr) {
+		if status := codeHttpStatus(bizErr.Code); status < http.StatusInternalServerError {
+			return status
+		}
+		return http.StatusBadRequest
+	}
+	return codeHttpStatus(CodeOf(err))
+}
+
+// IsRetryable 原因链中是否有可重试的错误
+func IsRetryable(err error) bool {
+	retryable := false
+	walk(err, func(e *Error) bool {
+		retryable = e.Retryable
+		return !retryable
+	})
+	return retryable
+}
+
+// FieldsOf 合并原因链中所有统一错误的字段，外层的同名字段优先
+func FieldsOf(err error) map[string]interface{} {
+	fields := make(map[string]interface{})
+	walk(err, func(e *Error) bool {
+		for k, v := range e.Fields() {
+			if _, exists := fields[k]; !exists {
+				fields[k] = v
+			}
+		}
+		return true
+	})
+	return fields
+}
+
+// walk 由外向内遍历原因链中的统一错误，包括 errors.Join 合并的错误，fn 返回 false 时停止
+func walk(err error, fn func(e *Error) bool) bool {
+	for err != nil {
+		if e, ok := err.(*Error); ok && !fn(e) {
+			return false
+		}
+		switch x := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, child := range x.Unwrap() {
+				if !walk(child, fn) {
+					return false
+				}
+			}
+			return true
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// HttpStatus 错误码对应的 HTTP 状态，优先使用注册的状态，未注册时按错误码区间映射：
+// 101-200 token 错误为 401，201-300 权限错误为 403，400-599 直接作为 HTTP 状态，
+// 4xxxx 为 400，5xxxx 为 500，6xxxx 及以上的业务模块错误为 400，其余为 500
+func HttpStatus(code RespCode) int {
+	return codeHttpStatus(code)
+}
+
+func codeHttpStatus(code RespCode) int {
+	if info, ok := Lookup(code); ok && info.HttpStatus != 0 {
+		return info.HttpStatus
+	}
+	switch {
+	case code == CodeSuccess:
+		return http.StatusOK
+	case code > 100 && code <= 200:
+		return http.StatusUnauthorized
+	case code > 200 && code <= 300:
+		return http.StatusForbidden
+	case code >= 400 && code < 600:
+		return int(code)
+	case code >= 40000 && code < 50000:
+		return http.StatusBadRequest
+	case code >= 60000:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,235 @@
+package tserror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("query user: %w", New(CodeUserNotFound, ""))
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"same code", err, New(CodeUserNotFound, "other message"), true},
+		{"different code", err, New(CodeUserExist, ""), false},
+		{"not coded", err, errors.New("用户不存在"), false},
+		{"wrapped cause", Wrap(errNotFoundSentinel, CodeDatabaseError, ""), errNotFoundSentinel, true},
+		{"nil", nil, New(CodeUserNotFound, ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Fatalf("errors.Is = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if New(CodeUserNotFound, "").Message != "用户不存在" {
+		t.Fatal("empty message should use the registered message")
+	}
+}
+
+var errNotFoundSentinel = errors.New("sentinel")
+
+func TestErrorAs(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *Error
+		wantBiz bool
+	}{
+		{"registered 4xx", New(CodeUserNotFound, ""), true},
+		{"registered 5xx", New(CodeDatabaseError, ""), false},
+		{"explicit status overrides code", New(CodeUserNotFound, "").WithHttpStatus(http.StatusServiceUnavailable), false},
+		{"explicit 4xx on system code", New(CodeServerInternalError, "").WithHttpStatus(http.StatusConflict), true},
+		{"unregistered business code", New(60099, "x"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", tt.err)
+			var bizErr *BizError
+			var sysErr *SystemError
+			if got := errors.As(err, &bizErr); got != tt.wantBiz {
+				t.Fatalf("As BizError = %v, want %v", got, tt.wantBiz)
+			}
+			if got := errors.As(err, &sysErr); got == tt.wantBiz {
+				t.Fatalf("As SystemError = %v, want %v", got, !tt.wantBiz)
+			}
+			if tt.wantBiz && (bizErr.Code != tt.err.Code || bizErr.Message != tt.err.Message) {
+				t.Fatalf("BizError = %+v", bizErr)
+			}
+			if !tt.wantBiz && (sysErr.Code != tt.err.Code || sysErr.Message != tt.err.Message) {
+				t.Fatalf("SystemError = %+v", sysErr)
+			}
+		})
+	}
+
+	// SystemError 的 Detail 和 Original 来自原因
+	cause := errors.New("connection refused")
+	var sysErr *SystemError
+	if !errors.As(Wrap(cause, CodeDatabaseError, ""), &sysErr) || sysErr.Detail != "connection refused" || sysErr.Original != cause {
+		t.Fatalf("SystemError = %+v", sysErr)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	first := New(CodeUserNotFound, "").With("user_id", 1)
+	second := New(CodeServiceUnavailable, "").WithRetryable(true)
+	plain := errors.New("plain")
+	err := Join(CodeServerInternalError, "batch failed", first, second, plain)
+
+	if err.Code != CodeServerInternalError || !strings.HasPrefix(err.Error(), "batch failed: ") {
+		t.Fatalf("Join = %v", err)
+	}
+	for _, target := range []error{first, second, plain, New(CodeServerInternalError, "")} {
+		if !errors.Is(err, target) {
+			t.Fatalf("errors.Is(join, %v) = false", target)
+		}
+	}
+	var inner *Error
+	if !errors.As(err.Unwrap(), &inner) || inner != first {
+		t.Fatalf("errors.As on joined errors = %v", inner)
+	}
+	if !IsRetryable(err) || FieldsOf(err)["user_id"] != 1 {
+		t.Fatalf("retryable = %v, fields = %v", IsRetryable(err), FieldsOf(err))
+	}
+}
+
+func TestWalkRetryableAndFields(t *testing.T) {
+	inner := New(CodeDBDeadlock, "").With("table", "user", "id", 1).WithRetryable(true)
+	outer := Wrap(fmt.Errorf("update: %w", inner), CodeDatabaseError, "").With("id", 2, "dangling")
+
+	var codes []RespCode
+	walk(outer, func(e *Error) bool {
+		codes = append(codes, e.Code)
+		return true
+	})
+	if len(codes) != 2 || codes[0] != CodeDatabaseError || codes[1] != CodeDBDeadlock {
+		t.Fatalf("walk order = %v", codes)
+	}
+
+	// fn 返回 false 时停止遍历
+	visited := 0
+	walk(outer, func(e *Error) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Fatalf("visited = %d after stop", visited)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"inner retryable", outer, true},
+		{"not retryable", New(CodeDatabaseError, ""), false},
+		{"plain error", errors.New("x"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 外层同名字段优先，缺少值的键为 nil
+	fields := FieldsOf(outer)
+	if fields["id"] != 2 || fields["table"] != "user" || fields["dangling"] != nil || len(fields) != 3 {
+		t.Fatalf("fields = %v", fields)
+	}
+	if len(FieldsOf(errors.New("x"))) != 0 {
+		t.Fatal("plain error should have no fields")
+	}
+}
+
+func TestFormatStack(t *testing.T) {
+	err := New(CodeUserNotFound, "").With("user_id", 7)
+	if got := fmt.Sprintf("%v", err); got != "用户不存在" {
+		t.Fatalf("%%v = %q", got)
+	}
+	if got := fmt.Sprintf("%q", err); got != `"用户不存在"` {
+		t.Fatalf("%%q = %s", got)
+	}
+	detail := fmt.Sprintf("%+v", err)
+	for _, want := range []string{"用户不存在", "fields: map[user_id:7]", "tserror.TestFormatStack", "coded_error_test.go:"} {
+		if !strings.Contains(detail, want) {
+			t.Fatalf("%q not in %%+v:\n%s", want, detail)
+		}
+	}
+
+	// 原因链中已有调用栈时不重复捕获，%+v 输出内层的调用栈
+	wrapped := Wrap(err, CodeDatabaseError, "")
+	if wrapped.StackTrace() != "" {
+		t.Fatal("wrap should not capture a second stack")
+	}
+	if detail := fmt.Sprintf("%+v", wrapped); !strings.Contains(detail, "fields: map[user_id:7]") ||
+		strings.Count(detail, "tserror.TestFormatStack") != 1 {
+		t.Fatalf("wrapped %%+v:\n%s", detail)
+	}
+
+	// pkg/errors 的调用栈通过 caused by 输出
+	pkgErr := Wrap(pkgerrors.New("driver"), CodeDatabaseError, "")
+	if detail := fmt.Sprintf("%+v", pkgErr); !strings.Contains(detail, "caused by: driver") ||
+		!strings.Contains(detail, "coded_error_test.go:") {
+		t.Fatalf("pkg/errors %%+v:\n%s", detail)
+	}
+}
+
+func TestHttpStatusMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		code RespCode
+		want int
+	}{
+		{"success", CodeSuccess, http.StatusOK},
+		{"token range", 150, http.StatusUnauthorized},
+		{"registered token code", CodeTokenEmpty, http.StatusUnauthorized},
+		{"permission range", 250, http.StatusForbidden},
+		{"http status as code", 404, http.StatusNotFound},
+		{"registered 404", CodeUserNotFound, http.StatusNotFound},
+		{"registered 409", CodeUserExist, http.StatusConflict},
+		{"registered 429", CodeTooManyRequests, http.StatusTooManyRequests},
+		{"registered 503", CodeServiceUnavailable, http.StatusServiceUnavailable},
+		{"unregistered 4xxxx", 40999, http.StatusBadRequest},
+		{"unregistered 5xxxx", 50999, http.StatusInternalServerError},
+		{"business module", 60999, http.StatusBadRequest},
+		{"unknown", 1000, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := HttpStatus(tt.code); got != tt.want {
+			t.Errorf("%s: HttpStatus(%d) = %d, want %d", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestHttpStatusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, http.StatusOK},
+		{"coded error", New(CodeUserNotFound, ""), http.StatusNotFound},
+		{"explicit status", New(CodeUserNotFound, "").WithHttpStatus(http.StatusGone), http.StatusGone},
+		{"biz error", NewBizErrCode(CodeTooManyRequests, ""), http.StatusTooManyRequests},
+		// BizError 即使使用了 5xx 的错误码也不会映射为 5xx
+		{"biz error with system code", NewBizErrCode(CodeServiceUnavailable, ""), http.StatusBadRequest},
+		{"biz error with 500 code", NewBizErrCode(500, "x"), http.StatusBadRequest},
+		{"system error", NewSystemErrCode(CodeServiceUnavailable, "", nil), http.StatusServiceUnavailable},
+		{"plain error", errors.New("x"), http.StatusInternalServerError},
+		{"wrapped", fmt.Errorf("get: %w", New(CodeUserExist, "")), http.StatusConflict},
+	}
+	for _, tt := range tests {
+		if got := HttpStatusOf(tt.err); got != tt.want {
+			t.Errorf("%s: HttpStatusOf = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
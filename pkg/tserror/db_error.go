@@ -0,0 +1,113 @@
+package tserror
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"zyj.com/golang-study/define"
+)
+
+// 数据库相关错误码
+const (
+	CodeRecordNotFound RespCode = 40005 // 记录不存在
+	CodeDuplicateEntry RespCode = 40006 // 记录已存在
+
+	CodeDBDeadlock           RespCode = 50004 // 数据库死锁
+	CodeDBLockWaitTimeout    RespCode = 50005 // 数据库锁等待超时
+	CodeDBTooManyConnections RespCode = 50006 // 数据库连接数过多
+)
+
+// MySQL 错误号
+const (
+	mysqlErrDuplicateEntry         = 1062
+	mysqlErrTooManyConnections     = 1040
+	mysqlErrUserTooManyConnections = 1203
+	mysqlErrLockWaitTimeout        = 1205
+	mysqlErrDeadlock               = 1213
+)
+
+// ErrEntityNotFound 按主键等条件查询不到记录
+var ErrEntityNotFound = errors.New("entity not found")
+
+var duplicateKeyRe = regexp.MustCompile(`for key '([^']+)'`)
+
+var (
+	duplicateKeyMu    sync.RWMutex
+	duplicateKeyCodes = make(map[string]RespCode)
+)
+
+func init() {
+	Register(
+		CodeInfo{Code: CodeRecordNotFound, HttpStatus: http.StatusNotFound, Message: "记录不存在",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "記錄不存在", define.LanguageEnglish: "Record not found"}},
+		CodeInfo{Code: CodeDuplicateEntry, HttpStatus: http.StatusConflict, Message: "记录已存在",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "記錄已存在", define.LanguageEnglish: "Record already exists"}},
+		CodeInfo{Code: CodeDBDeadlock, HttpStatus: http.StatusServiceUnavailable, Message: "系统繁忙，请稍后重试",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "系統繁忙，請稍後重試", define.LanguageEnglish: "System busy, please try again later"}},
+		CodeInfo{Code: CodeDBLockWaitTimeout, HttpStatus: http.StatusServiceUnavailable, Message: "系统繁忙，请稍后重试",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "系統繁忙，請稍後重試", define.LanguageEnglish: "System busy, please try again later"}},
+		CodeInfo{Code: CodeDBTooManyConnections, HttpStatus: http.StatusServiceUnavailable, Message: "系统繁忙，请稍后重试",
+			Translations: map[int]string{define.LanguageTraditionalChinese: "系統繁忙，請稍後重試", define.LanguageEnglish: "System busy, please try again later"}},
+	)
+}
+
+// RegisterDuplicateKey 指定唯一索引冲突时使用的错误码，key 为索引名，如 UQE_users_email
+func RegisterDuplicateKey(key string, code RespCode) {
+	duplicateKeyMu.Lock()
+	defer duplicateKeyMu.Unlock()
+	duplicateKeyCodes[key] = code
+}
+
+// duplicateKeyCode 唯一索引冲突对应的错误码，MySQL 8 的索引名带有 表名. 前缀
+func duplicateKeyCode(key string) RespCode {
+	if idx := strings.LastIndexByte(key, '.'); idx >= 0 {
+		key = key[idx+1:]
+	}
+	duplicateKeyMu.RLock()
+	defer duplicateKeyMu.RUnlock()
+	if code, ok := duplicateKeyCodes[key]; ok {
+		return code
+	}
+	return CodeDuplicateEntry
+}
+
+// TranslateDBError 将数据库和驱动错误转换为带错误码的 Error，原始错误保留在原因链中：
+// 记录不存在、唯一索引冲突、死锁、锁等待超时和连接数过多有各自的错误码，其余为 CodeDatabaseError
+func TranslateDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	if errors.Is(err, ErrEntityNotFound) || errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, sql.ErrNoRows) || err.Error() == ErrEntityNotFound.Error() {
+		return newError(CodeRecordNotFound, "", err)
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrDuplicateEntry:
+			key := ""
+			if m := duplicateKeyRe.FindStringSubmatch(mysqlErr.Message); m != nil {
+				key = m[1]
+			}
+			return newError(duplicateKeyCode(key), "", err).With("mysql_errno", mysqlErr.Number, "key", key)
+		case mysqlErrDeadlock:
+			return newError(CodeDBDeadlock, "", err).With("mysql_errno", mysqlErr.Number).WithRetryable(true)
+		case mysqlErrLockWaitTimeout:
+			return newError(CodeDBLockWaitTimeout, "", err).With("mysql_errno", mysqlErr.Number).WithRetryable(true)
+		case mysqlErrTooManyConnections, mysqlErrUserTooManyConnections:
+			return newError(CodeDBTooManyConnections, "", err).With("mysql_errno", mysqlErr.Number).WithRetryable(true)
+		}
+		return newError(CodeDatabaseError, "", err).With("mysql_errno", mysqlErr.Number)
+	}
+	return newError(CodeDatabaseError, "", err)
+}
@@ -0,0 +1,81 @@
+package tserror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestTranslateDBError(t *testing.T) {
+	const codeEmailTaken RespCode = 60010
+	RegisterDuplicateKey("UQE_users_email", codeEmailTaken)
+	t.Cleanup(func() {
+		duplicateKeyMu.Lock()
+		delete(duplicateKeyCodes, "UQE_users_email")
+		duplicateKeyMu.Unlock()
+	})
+
+	duplicate := func(key string) error {
+		return &mysql.MySQLError{Number: 1062, Message: fmt.Sprintf("Duplicate entry 'a@b.com' for key '%s'", key)}
+	}
+	tests := []struct {
+		name      string
+		err       error
+		code      RespCode
+		status    int
+		retryable bool
+		fields    map[string]interface{}
+	}{
+		{"registered duplicate key", duplicate("UQE_users_email"), codeEmailTaken, http.StatusBadRequest, false,
+			map[string]interface{}{"mysql_errno": uint16(1062), "key": "UQE_users_email"}},
+		{"mysql 8 table prefix", duplicate("users.UQE_users_email"), codeEmailTaken, http.StatusBadRequest, false,
+			map[string]interface{}{"key": "users.UQE_users_email"}},
+		{"unregistered duplicate key", duplicate("PRIMARY"), CodeDuplicateEntry, http.StatusConflict, false, nil},
+		{"deadlock", &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, CodeDBDeadlock, http.StatusServiceUnavailable, true,
+			map[string]interface{}{"mysql_errno": uint16(1213)}},
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, CodeDBLockWaitTimeout, http.StatusServiceUnavailable, true, nil},
+		{"too many connections", &mysql.MySQLError{Number: 1040, Message: "Too many connections"}, CodeDBTooManyConnections, http.StatusServiceUnavailable, true, nil},
+		{"user too many connections", &mysql.MySQLError{Number: 1203, Message: "User has too many connections"}, CodeDBTooManyConnections, http.StatusServiceUnavailable, true, nil},
+		{"other mysql error", &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}, CodeDatabaseError, http.StatusInternalServerError, false,
+			map[string]interface{}{"mysql_errno": uint16(1146)}},
+		{"gorm not found", gorm.ErrRecordNotFound, CodeRecordNotFound, http.StatusNotFound, false, nil},
+		{"sql no rows", fmt.Errorf("scan: %w", sql.ErrNoRows), CodeRecordNotFound, http.StatusNotFound, false, nil},
+		{"entity not found", ErrEntityNotFound, CodeRecordNotFound, http.StatusNotFound, false, nil},
+		{"wrapped mysql error", fmt.Errorf("insert: %w", &mysql.MySQLError{Number: 1213}), CodeDBDeadlock, http.StatusServiceUnavailable, true, nil},
+		{"plain error", errors.New("driver: bad connection"), CodeDatabaseError, http.StatusInternalServerError, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateDBError(tt.err)
+			if CodeOf(err) != tt.code || HttpStatusOf(err) != tt.status || IsRetryable(err) != tt.retryable {
+				t.Fatalf("code = %d, status = %d, retryable = %v", CodeOf(err), HttpStatusOf(err), IsRetryable(err))
+			}
+			// 原始错误保留在原因链中
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("original error lost: %v", err)
+			}
+			fields := FieldsOf(err)
+			for key, want := range tt.fields {
+				if fields[key] != want {
+					t.Fatalf("%s = %v (%T), want %v", key, fields[key], fields[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateDBErrorPassThrough(t *testing.T) {
+	if TranslateDBError(nil) != nil {
+		t.Fatal("nil should stay nil")
+	}
+	// 已经是统一错误时原样返回
+	coded := New(CodeUserNotFound, "")
+	if err := TranslateDBError(fmt.Errorf("get: %w", coded)); !errors.Is(err, coded) || CodeOf(err) != CodeUserNotFound {
+		t.Fatalf("coded error translated: %v", err)
+	}
+}
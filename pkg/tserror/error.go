@@ -19,6 +19,7 @@ const (
 	CodeParamBind        RespCode = 40002 // 参数绑定失败
 	CodeUnauthorized     RespCode = 40003 // 未授权
 	CodePermissionDenied RespCode = 40004 // 权限不足
+	CodeTooManyRequests  RespCode = 40007 // 请求过于频繁
 )
 
 // 业务模块错误码（用户模块示例）
@@ -32,24 +33,9 @@ const (
 	CodeServerInternalError  RespCode = 50001 // 服务器内部错误
 	CodeDatabaseError        RespCode = 50002 // 数据库错误
 	CodeExternalServiceError RespCode = 50003 // 外部服务错误
+	CodeServiceUnavailable   RespCode = 50007 // 服务暂不可用
 )
 
-// 错误码与消息映射
-var errorMessages = map[RespCode]string{
-	CodeSuccess:             "成功",
-	CodeParamInvalid:        "参数验证失败",
-	CodeUserNotFound:        "用户不存在",
-	CodeServerInternalError: "系统内部错误",
-}
-
-// GetErrorMessage 获取错误消息
-func GetErrorMessage(code RespCode) string {
-	if msg, exists := errorMessages[code]; exists {
-		return msg
-	}
-	return "未知错误"
-}
-
 const (
 	HTTP_NOT_FOUND         HttpCode = 404
 	HTTP_TOO_MANY_REQUESTS HttpCode = 429
@@ -73,7 +59,11 @@ func NewBizError(message string) error {
 	}, message)
 }
 
+// NewBizErrCode 创建指定错误码的业务错误，message 为空时使用注册的默认消息
 func NewBizErrCode(code RespCode, message string) error {
+	if message == "" {
+		message = GetErrorMessage(code)
+	}
 	return errors.Wrap(&BizError{
 		Code:    code,
 		Message: message,
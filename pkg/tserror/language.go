@@ -0,0 +1,44 @@
+package tserror
+
+import (
+	"strconv"
+	"strings"
+
+	"zyj.com/golang-study/define"
+)
+
+// ParseAcceptLanguage 根据 Accept-Language 请求头选择响应语言，按 q 值优先，
+// 无法识别时返回简体中文
+func ParseAcceptLanguage(header string) int {
+	lang, bestQ := define.LanguageSimplifiedChinese, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		candidate, ok := languageOfTag(tag)
+		if ok && q > bestQ {
+			lang, bestQ = candidate, q
+		}
+	}
+	return lang
+}
+
+// languageOfTag 将语言标签映射到 define.LanguageXxx
+func languageOfTag(tag string) (int, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case tag == "zh-tw" || tag == "zh-hk" || tag == "zh-mo" || strings.HasPrefix(tag, "zh-hant"):
+		return define.LanguageTraditionalChinese, true
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		return define.LanguageSimplifiedChinese, true
+	case tag == define.En || strings.HasPrefix(tag, define.En+"-"):
+		return define.LanguageEnglish, true
+	}
+	return 0, false
+}
@@ -0,0 +1,36 @@
+package tserror
+
+import (
+	"testing"
+
+	"zyj.com/golang-study/define"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", define.LanguageSimplifiedChinese},
+		{"en", define.LanguageEnglish},
+		{"en-US,en;q=0.9", define.LanguageEnglish},
+		{"zh-CN", define.LanguageSimplifiedChinese},
+		{"zh-TW", define.LanguageTraditionalChinese},
+		{"zh-HK", define.LanguageTraditionalChinese},
+		{"zh-Hant-TW", define.LanguageTraditionalChinese},
+		{"zh-Hans-CN", define.LanguageSimplifiedChinese},
+		// 按 q 值选择，而不是按出现顺序
+		{"en;q=0.5, zh-TW;q=0.8", define.LanguageTraditionalChinese},
+		{"zh;q=0.3, EN;q=0.7", define.LanguageEnglish},
+		// 无法识别的语言和非法 q 值被忽略
+		{"fr-FR, en;q=0.1", define.LanguageEnglish},
+		{"en;q=abc, zh-TW;q=0.2", define.LanguageTraditionalChinese},
+		{"fr, de;q=0.9", define.LanguageSimplifiedChinese},
+		{"en;q=0", define.LanguageSimplifiedChinese},
+	}
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); got != tt.want {
+			t.Errorf("ParseAcceptLanguage(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tslog
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"zyj.com/golang-study/define"
+	"zyj.com/golang-study/util/ginutil"
+)
+
+// AccessLogConfig gin 访问日志配置
+type AccessLogConfig struct {
+	Logger    *Logger  // 输出使用的日志器，默认 Default().Named("access")
+	SkipPaths []string // 不记录的路径，如健康检查
+}
+
+// GinAccessLog 每个请求输出一条结构化访问日志
+func GinAccessLog() gin.HandlerFunc {
+	return GinAccessLogWithConfig(AccessLogConfig{})
+}
+
+// GinAccessLogWithConfig 使用指定配置创建访问日志中间件
+func GinAccessLogWithConfig(config AccessLogConfig) gin.HandlerFunc {
+	logger := config.Logger
+	if logger == nil {
+		logger = Default().Named("access")
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		// 提前生成 trace_id，保证处理过程中的日志与访问日志一致
+		traceID := ginutil.GetTraceID(c)
+		c.Header(define.HEADER_TRACE_ID_KEY, traceID)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		fields := []zapcore.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user_agent", c.Request.UserAgent()),
+			zap.Int64("req_size", max(c.Request.ContentLength, 0)),
+			zap.Int("resp_size", max(c.Writer.Size(), 0)),
+		}
+		if code, ok := c.Get(define.RESPONSE_ERROR_CODE); ok {
+			fields = append(fields, zap.Any(define.RESPONSE_ERROR_CODE, code))
+		}
+		if msg := c.GetString(define.LOG_DEBUG_MSG); msg != "" {
+			fields = append(fields, zap.String(define.LOG_DEBUG_MSG, msg))
+		}
+		if detail := c.GetString(define.RESPONSE_ERROR_DETAIL_MSG); detail != "" {
+			fields = append(fields, zap.String(define.RESPONSE_ERROR_DETAIL_MSG, detail))
+		}
+		if stack := c.GetString(define.RESPONSE_ERROR_STACK); stack != "" {
+			fields = append(fields, zap.String(define.RESPONSE_ERROR_STACK, stack))
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			fields = append(fields, zap.String("gin_errors", errs))
+		}
+		fields = append(fields, contextFields(c)...)
+
+		zl := logger.Zap().WithOptions(zap.WithCaller(false))
+		// 所有错误都带调用栈，级别只按状态码区分
+		switch {
+		case status >= http.StatusInternalServerError:
+			zl.Error("access", fields...)
+		case status >= http.StatusBadRequest:
+			zl.Warn("access", fields...)
+		default:
+			zl.Info("access", fields...)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tslog
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"zyj.com/golang-study/define"
+)
+
+func newAccessLogRouter(t *testing.T) (*gin.Engine, *observer.ObservedLogs) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := gin.New()
+	router.Use(GinAccessLogWithConfig(AccessLogConfig{Logger: newLogger(zap.New(core)), SkipPaths: []string{"/health"}}))
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/users", func(c *gin.Context) {
+		c.Set(define.RESPONSE_ERROR_CODE, 1001)
+		c.Set(define.RESPONSE_ERROR_STACK, "biz stack")
+		c.Error(errors.New("bad input"))
+		c.Status(http.StatusBadRequest)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		c.Set(define.RESPONSE_ERROR_STACK, "goroutine 1")
+		c.Status(http.StatusInternalServerError)
+	})
+	return router, logs
+}
+
+func TestGinAccessLog(t *testing.T) {
+	router, logs := newAccessLogRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(define.HEADER_TRACE_ID_KEY, "t1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Header().Get(define.HEADER_TRACE_ID_KEY) != "t1" {
+		t.Fatalf("trace id header = %q", w.Header().Get(define.HEADER_TRACE_ID_KEY))
+	}
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	entry := entries[0]
+	fields := entry.ContextMap()
+	if entry.Level != zapcore.InfoLevel || entry.Caller.Defined {
+		t.Fatalf("entry = %+v", entry)
+	}
+	want := map[string]interface{}{
+		"method": "GET", "path": "/users/1", "route": "/users/:id", "status": int64(200),
+		"resp_size": int64(2), "req_size": int64(0), "trace_id": "t1",
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Fatalf("%s = %v, want %v (fields %v)", key, fields[key], value, fields)
+		}
+	}
+}
+
+func TestGinAccessLogGeneratesTraceID(t *testing.T) {
+	router, logs := newAccessLogRouter(t)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	traceID := w.Header().Get(define.HEADER_TRACE_ID_KEY)
+	if traceID == "" || logs.All()[0].ContextMap()["trace_id"] != traceID {
+		t.Fatalf("trace id header %q, log %v", traceID, logs.All()[0].ContextMap()["trace_id"])
+	}
+}
+
+func TestGinAccessLogLevels(t *testing.T) {
+	router, logs := newAccessLogRouter(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/health", nil),
+		httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("{}")),
+		httptest.NewRequest(http.MethodGet, "/panic", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// 跳过的路径不记录
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	// 带调用栈的 4xx 仍为 warn
+	bad := entries[0].ContextMap()
+	if entries[0].Level != zapcore.WarnLevel || bad[define.RESPONSE_ERROR_CODE] != int64(1001) ||
+		bad["gin_errors"] == nil || bad["req_size"] != int64(2) || bad[define.RESPONSE_ERROR_STACK] != "biz stack" {
+		t.Fatalf("4xx entry = %v %v", entries[0].Level, bad)
+	}
+	if entries[1].Level != zapcore.ErrorLevel || entries[1].ContextMap()[define.RESPONSE_ERROR_STACK] != "goroutine 1" {
+		t.Fatalf("5xx entry = %v %v", entries[1].Level, entries[1].ContextMap())
+	}
+}
@@ -0,0 +1,264 @@
+package tslog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 缓冲区满时的处理策略
+const (
+	OverflowBlock          = "block"            // 等待后台写出腾出空间
+	OverflowDropDebugFirst = "drop-debug-first" // 优先丢弃缓冲中级别最低的日志，error 及以上从不丢弃
+)
+
+const (
+	defaultAsyncBufferSize    = 8192
+	defaultAsyncFlushInterval = time.Second
+)
+
+// AsyncConfig 异步写入配置，日志在调用方编码后放入环形缓冲，由后台 goroutine 写出
+type AsyncConfig struct {
+	BufferSize     int           // 环形缓冲可容纳的日志条数，默认 8192
+	FlushInterval  time.Duration // 定期刷新底层写入器的间隔，默认 1 秒
+	OverflowPolicy string        // 缓冲区满时的处理策略: block, drop-debug-first，默认 block
+}
+
+// droppedLogs 各级别因缓冲区满被丢弃的日志条数
+var droppedLogs [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Uint64
+
+// DroppedLogs 获取各级别被丢弃的日志条数
+func DroppedLogs() map[string]uint64 {
+	dropped := make(map[string]uint64)
+	for i := range droppedLogs {
+		if n := droppedLogs[i].Load(); n > 0 {
+			dropped[(zapcore.DebugLevel + zapcore.Level(i)).String()] = n
+		}
+	}
+	return dropped
+}
+
+func countDropped(level zapcore.Level) {
+	if level >= zapcore.DebugLevel && level <= zapcore.FatalLevel {
+		droppedLogs[level-zapcore.DebugLevel].Add(1)
+	}
+}
+
+type asyncEntry struct {
+	level zapcore.Level
+	data  []byte
+}
+
+// asyncWriter 有界环形缓冲加后台写出
+type asyncWriter struct {
+	out      zapcore.WriteSyncer
+	dropLow  bool
+	interval time.Duration
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    []asyncEntry
+	head    int // 最早一条的位置
+	size    int
+
+	writeMu sync.Mutex // 保证后台写出与 Sync 按顺序写入
+	notify  chan struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{} // 后台写出goroutine退出信号
+}
+
+func newAsyncWriter(out zapcore.WriteSyncer, config *AsyncConfig) *asyncWriter {
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultAsyncBufferSize
+	}
+	interval := config.FlushInterval
+	if interval <= 0 {
+		interval = defaultAsyncFlushInterval
+	}
+	w := &asyncWriter{
+		out:      out,
+		dropLow:  config.OverflowPolicy == OverflowDropDebugFirst,
+		interval: interval,
+		ring:     make([]asyncEntry, bufferSize),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// push 放入一条已编码的日志，缓冲区满时按策略等待或丢弃
+func (w *asyncWriter) push(level zapcore.Level, data []byte) {
+	w.mu.Lock()
+	for w.size == len(w.ring) {
+		if w.dropLow && w.dropLowest(level) {
+			break
+		}
+		if w.dropLow && level < zapcore.ErrorLevel {
+			w.mu.Unlock()
+			countDropped(level)
+			return
+		}
+		select {
+		case <-w.stop:
+			// 后台写出已停止，由调用方自行写出腾出空间
+			w.mu.Unlock()
+			w.drain()
+			w.mu.Lock()
+			continue
+		default:
+		}
+		w.wake()
+		w.notFull.Wait()
+	}
+	w.ring[(w.head+w.size)%len(w.ring)] = asyncEntry{level: level, data: data}
+	w.size++
+	w.mu.Unlock()
+	w.wake()
+}
+
+// dropLowest 丢弃缓冲中级别最低且低于 level 的最早一条日志，调用方持有 mu
+func (w *asyncWriter) dropLowest(level zapcore.Level) bool {
+	lowest := -1
+	for i := 0; i < w.size; i++ {
+		e := w.ring[(w.head+i)%len(w.ring)]
+		if e.level < level && e.level < zapcore.ErrorLevel &&
+			(lowest < 0 || e.level < w.ring[(w.head+lowest)%len(w.ring)].level) {
+			lowest = i
+		}
+	}
+	if lowest < 0 {
+		return false
+	}
+	countDropped(w.ring[(w.head+lowest)%len(w.ring)].level)
+	// 将被丢弃位置之后的日志前移，保持顺序
+	for i := lowest; i < w.size-1; i++ {
+		w.ring[(w.head+i)%len(w.ring)] = w.ring[(w.head+i+1)%len(w.ring)]
+	}
+	w.size--
+	w.ring[(w.head+w.size)%len(w.ring)] = asyncEntry{}
+	return true
+}
+
+func (w *asyncWriter) wake() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take 取出缓冲中的全部日志
+func (w *asyncWriter) take() []asyncEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size == 0 {
+		return nil
+	}
+	entries := make([]asyncEntry, w.size)
+	for i := range entries {
+		idx := (w.head + i) % len(w.ring)
+		entries[i] = w.ring[idx]
+		w.ring[idx] = asyncEntry{}
+	}
+	w.head = (w.head + w.size) % len(w.ring)
+	w.size = 0
+	w.notFull.Broadcast()
+	return entries
+}
+
+// drain 写出缓冲中的全部日志
+func (w *asyncWriter) drain() {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	for _, e := range w.take() {
+		if _, err := w.out.Write(e.data); err != nil {
+			fmt.Fprintf(os.Stderr, "tslog: async write failed: %v\n", err)
+		}
+	}
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.notify:
+			w.drain()
+		case <-ticker.C:
+			w.drain()
+			_ = w.out.Sync()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// close 停止后台写出，并同步写出缓冲中剩余的日志
+func (w *asyncWriter) close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		_ = w.Sync()
+	})
+}
+
+// Sync 同步写出缓冲中的全部日志并刷新底层写入器
+func (w *asyncWriter) Sync() error {
+	w.drain()
+	return w.out.Sync()
+}
+
+// asyncCore 在调用方编码日志，写入交给 asyncWriter
+type asyncCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *asyncWriter
+}
+
+func newAsyncCore(encoder zapcore.Encoder, out zapcore.WriteSyncer, level zapcore.LevelEnabler, config *AsyncConfig) *asyncCore {
+	return &asyncCore{LevelEnabler: level, encoder: encoder, writer: newAsyncWriter(out, config)}
+}
+
+func (c *asyncCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &asyncCore{LevelEnabler: c.LevelEnabler, encoder: encoder, writer: c.writer}
+}
+
+func (c *asyncCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *asyncCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Free()
+	c.writer.push(entry.Level, data)
+	// panic 和 fatal 之后进程可能退出，立即写出
+	if entry.Level > zapcore.ErrorLevel {
+		return c.writer.Sync()
+	}
+	return nil
+}
+
+func (c *asyncCore) Sync() error {
+	return c.writer.Sync()
+}
@@ -0,0 +1,170 @@
+package tslog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// gatedWriter 第一次写入时阻塞到 release，用于让后台写出停在固定位置
+type gatedWriter struct {
+	started chan struct{}
+	gate    chan struct{}
+	once    sync.Once
+
+	mu    sync.Mutex
+	lines []string
+	syncs int
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{started: make(chan struct{}), gate: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.gate
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (w *gatedWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncs++
+	return nil
+}
+
+func (w *gatedWriter) release() {
+	close(w.gate)
+}
+
+func (w *gatedWriter) written() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.lines...)
+}
+
+func newAsyncTestLogger(out zapcore.WriteSyncer, config *AsyncConfig) (*zap.Logger, zapcore.Core) {
+	core := newAsyncCore(zapcore.NewConsoleEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), out, zapcore.DebugLevel, config)
+	return zap.New(core), core
+}
+
+func TestAsyncDropDebugFirst(t *testing.T) {
+	out := newGatedWriter()
+	logger, core := newAsyncTestLogger(out, &AsyncConfig{BufferSize: 3, FlushInterval: time.Hour, OverflowPolicy: OverflowDropDebugFirst})
+	before := DroppedLogs()
+
+	logger.Info("first")
+	<-out.started
+	logger.Debug("d1")
+	logger.Info("i1")
+	logger.Debug("d2")
+	// 缓冲区已满：优先丢弃缓冲中级别最低的最早一条，没有更低级别时丢弃新日志，error 从不丢弃
+	logger.Info("i2")
+	logger.Debug("d3")
+	logger.Error("e1")
+	logger.Error("e2")
+	logger.Error("e3")
+
+	out.release()
+	if err := core.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(out.written(), ","); got != "first,e1,e2,e3" {
+		t.Fatalf("written = %s", got)
+	}
+	after := DroppedLogs()
+	if after["debug"]-before["debug"] != 3 || after["info"]-before["info"] != 2 || after["error"] != before["error"] {
+		t.Fatalf("dropped before %v, after %v", before, after)
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	out := newGatedWriter()
+	logger, core := newAsyncTestLogger(out, &AsyncConfig{BufferSize: 1, FlushInterval: time.Hour})
+
+	logger.Info("first")
+	<-out.started
+	logger.Debug("a")
+	// 缓冲区满时等待后台写出，不丢弃
+	done := make(chan struct{})
+	go func() {
+		logger.Debug("b")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("push did not block on a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	out.release()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("push still blocked after the buffer drained")
+	}
+	if err := core.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(out.written(), ","); got != "first,a,b" {
+		t.Fatalf("written = %s", got)
+	}
+}
+
+func TestAsyncSyncFlushes(t *testing.T) {
+	out := newGatedWriter()
+	out.release()
+	logger, _ := newAsyncTestLogger(out, &AsyncConfig{FlushInterval: time.Hour})
+
+	for i := 0; i < 100; i++ {
+		logger.Info("line")
+	}
+	// Sync 返回时缓冲中的日志全部写出并刷新底层写入器
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(out.written()); got != 100 {
+		t.Fatalf("written %d lines after Sync, want 100", got)
+	}
+
+	// panic 及以上级别立即写出
+	logger.With(zap.String("k", "v")).DPanic("dpanic")
+	lines := out.written()
+	if len(lines) != 101 || lines[100] != `dpanic	{"k": "v"}` {
+		t.Fatalf("last line = %q", lines[len(lines)-1])
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if out.syncs < 2 {
+		t.Fatalf("syncs = %d", out.syncs)
+	}
+}
+
+func TestAsyncCloseStopsWriter(t *testing.T) {
+	out := newGatedWriter()
+	out.release()
+	core := newAsyncCore(zapcore.NewConsoleEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), out, zapcore.DebugLevel, &AsyncConfig{FlushInterval: time.Hour})
+	zap.New(core).Info("last")
+
+	// close 写出剩余日志，可重复调用
+	core.writer.close()
+	core.writer.close()
+	select {
+	case <-core.writer.done:
+	default:
+		t.Fatal("run not stopped")
+	}
+	if lines := out.written(); len(lines) != 1 || lines[0] != "last" {
+		t.Fatalf("written = %q", lines)
+	}
+}
@@ -0,0 +1,141 @@
+package tslog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"zyj.com/golang-study/define"
+	"zyj.com/golang-study/util/ginutil"
+)
+
+// ContextFn 从 context 中提取日志字段
+type ContextFn func(ctx context.Context) []zapcore.Field
+
+// ginFieldsKey gin.Context 中保存 WithFields 字段的键
+const ginFieldsKey = "tslog_fields"
+
+// fieldsKey 标准 context 中保存 WithFields 字段的键
+type fieldsKey struct{}
+
+var (
+	contextFnsMu sync.RWMutex
+	contextFns   = []ContextFn{
+		TraceIDContextFn,
+		SpanIDContextFn,
+		UserIDContextFn,
+		AppIDContextFn,
+		TenantContextFn,
+		attachedFieldsContextFn,
+	}
+)
+
+// RegisterContextFn 注册 context 字段提取器，
+// InfoCtx/WarnCtx/ErrorCtx 以及 XLogger 都会依次调用所有提取器
+func RegisterContextFn(fn ContextFn) {
+	contextFnsMu.Lock()
+	defer contextFnsMu.Unlock()
+	contextFns = append(contextFns, fn)
+}
+
+// contextFields 依次调用所有已注册的提取器，合并提取到的字段
+func contextFields(ctx context.Context) []zapcore.Field {
+	if ctx == nil {
+		return nil
+	}
+	contextFnsMu.RLock()
+	defer contextFnsMu.RUnlock()
+	var fields []zapcore.Field
+	for _, fn := range contextFns {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
+
+// contextString 依次从 context.Value、gin 请求头中读取字符串
+func contextString(ctx context.Context, key string) string {
+	if value, ok := ctx.Value(key).(string); ok && value != "" {
+		return value
+	}
+	if ginCtx, ok := ctx.(*gin.Context); ok && ginCtx.Request != nil {
+		return ginCtx.GetHeader(key)
+	}
+	return ""
+}
+
+func stringField(key, value string) []zapcore.Field {
+	if value == "" {
+		return nil
+	}
+	return []zapcore.Field{zap.String(key, value)}
+}
+
+// TraceIDContextFn 提取 trace_id
+func TraceIDContextFn(ctx context.Context) []zapcore.Field {
+	if traceID, ok := ctx.Value(define.HEADER_TRACE_ID_KEY).(string); ok && traceID != "" {
+		return stringField("trace_id", traceID)
+	}
+	if ginCtx, ok := ctx.(*gin.Context); ok {
+		return stringField("trace_id", ginutil.GetTraceID(ginCtx))
+	}
+	return nil
+}
+
+// SpanIDContextFn 提取 span_id
+func SpanIDContextFn(ctx context.Context) []zapcore.Field {
+	return stringField("span_id", contextString(ctx, define.HEADER_SPAN_ID_KEY))
+}
+
+// UserIDContextFn 提取 user_id
+func UserIDContextFn(ctx context.Context) []zapcore.Field {
+	if ginCtx, ok := ctx.(*gin.Context); ok && ginCtx.Request != nil {
+		if userID := ginutil.GetUserID(ginCtx); userID != "" {
+			return stringField("user_id", userID)
+		}
+	}
+	if userID, ok := ctx.Value(define.HEADER_USER_ID_KEY).(string); ok {
+		return stringField("user_id", userID)
+	}
+	return nil
+}
+
+// AppIDContextFn 提取 app_id
+func AppIDContextFn(ctx context.Context) []zapcore.Field {
+	return stringField("app_id", contextString(ctx, define.App_ID_KEY))
+}
+
+// TenantContextFn 提取 tenant_id
+func TenantContextFn(ctx context.Context) []zapcore.Field {
+	return stringField("tenant_id", contextString(ctx, define.HEADER_TENANT_ID_KEY))
+}
+
+// WithFields 将字段附加到 context，之后使用该 context 输出的日志都会带上这些字段
+// 传入 gin.Context 时字段保存在 gin.Context 中并返回原 context
+func WithFields(ctx context.Context, fields ...zapcore.Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	merged := append(append([]zapcore.Field{}, attachedFields(ctx)...), fields...)
+	if ginCtx, ok := ctx.(*gin.Context); ok {
+		ginCtx.Set(ginFieldsKey, merged)
+		return ginCtx
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// attachedFields 获取 WithFields 附加的字段
+func attachedFields(ctx context.Context) []zapcore.Field {
+	if ginCtx, ok := ctx.(*gin.Context); ok {
+		if fields, ok := ginCtx.Value(ginFieldsKey).([]zapcore.Field); ok {
+			return fields
+		}
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zapcore.Field)
+	return fields
+}
+
+func attachedFieldsContextFn(ctx context.Context) []zapcore.Field {
+	return attachedFields(ctx)
+}
@@ -0,0 +1,82 @@
+package tslog
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"zyj.com/golang-study/define"
+)
+
+func fieldMap(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+func TestContextFieldsFromContextValues(t *testing.T) {
+	ctx := context.WithValue(context.Background(), define.HEADER_TRACE_ID_KEY, "t1")
+	ctx = context.WithValue(ctx, define.HEADER_USER_ID_KEY, "u1")
+	ctx = context.WithValue(ctx, define.HEADER_TENANT_ID_KEY, "tenant")
+	ctx = WithFields(ctx, zap.String("order_id", "o1"))
+	ctx = WithFields(ctx, zap.Int("attempt", 2))
+
+	fields := fieldMap(contextFields(ctx))
+	want := map[string]interface{}{
+		"trace_id": "t1", "user_id": "u1", "tenant_id": "tenant", "order_id": "o1", "attempt": int64(2),
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Fatalf("%s = %v, want %v (fields %v)", key, fields[key], value, fields)
+		}
+	}
+	if _, ok := fields["span_id"]; ok {
+		t.Fatal("empty span_id should be omitted")
+	}
+	if contextFields(nil) != nil {
+		t.Fatal("nil context should have no fields")
+	}
+}
+
+func TestContextFieldsFromGinContext(t *testing.T) {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest("GET", "/", nil)
+	ginCtx.Request.Header.Set(define.HEADER_TRACE_ID_KEY, "t2")
+	ginCtx.Request.Header.Set(define.HEADER_SPAN_ID_KEY, "s2")
+	ginCtx.Request.Header.Set(define.HEADER_USER_ID_KEY, "u2")
+	// gin.Context 上附加的字段保存在 gin.Context 中
+	if got := WithFields(ginCtx, zap.String("order_id", "o2")); got != ginCtx {
+		t.Fatal("WithFields should return the gin.Context itself")
+	}
+
+	fields := fieldMap(contextFields(ginCtx))
+	want := map[string]interface{}{"trace_id": "t2", "span_id": "s2", "user_id": "u2", "order_id": "o2"}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Fatalf("%s = %v, want %v (fields %v)", key, fields[key], value, fields)
+		}
+	}
+}
+
+func TestRegisterContextFn(t *testing.T) {
+	contextFnsMu.RLock()
+	old := contextFns
+	contextFnsMu.RUnlock()
+	t.Cleanup(func() {
+		contextFnsMu.Lock()
+		contextFns = old
+		contextFnsMu.Unlock()
+	})
+
+	RegisterContextFn(func(ctx context.Context) []zapcore.Field {
+		return []zapcore.Field{zap.String("region", "cn")}
+	})
+	if fields := fieldMap(contextFields(context.Background())); fields["region"] != "cn" {
+		t.Fatalf("fields = %v", fields)
+	}
+}
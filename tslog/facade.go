@@ -0,0 +1,204 @@
+package tslog
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger 统一日志门面
+// 包级日志函数、With/Named 创建的子日志器以及 gorm 的 XLogger 都通过它输出，
+// 零值不可用，请使用 Default、With 或 Named 获取
+type Logger struct {
+	parent *Logger                       // 父日志器，为空表示根日志器
+	derive func(*zap.Logger) *zap.Logger // 由父日志器派生当前日志器
+	name   string                        // 完整名称，用于按名称设置级别
+	state  atomic.Pointer[loggerState]
+}
+
+// loggerState 门面当前使用的 zap 日志器，Init 时整体替换
+type loggerState struct {
+	src    *zap.Logger        // 派生时父日志器的 base，变化后重新派生
+	base   *zap.Logger        // 原始日志器，调用者为直接调用处
+	method *zap.Logger        // 跳过门面方法或包级函数一层
+	sugar  *zap.SugaredLogger // 跳过门面方法或包级函数一层
+}
+
+func newLogger(base *zap.Logger) *Logger {
+	l := &Logger{}
+	l.set(base)
+	return l
+}
+
+func newLoggerState(src, base *zap.Logger) *loggerState {
+	method := base.WithOptions(zap.AddCallerSkip(1))
+	return &loggerState{
+		src:    src,
+		base:   base,
+		method: method,
+		sugar:  method.Sugar(),
+	}
+}
+
+func (l *Logger) set(base *zap.Logger) {
+	l.state.Store(newLoggerState(nil, base))
+}
+
+// load 获取当前 zap 日志器，子日志器在父日志器重新初始化后自动重新派生
+func (l *Logger) load() *loggerState {
+	s := l.state.Load()
+	if l.parent == nil {
+		return s
+	}
+	src := l.parent.load().base
+	if s == nil || s.src != src {
+		s = newLoggerState(src, l.derive(src))
+		l.state.Store(s)
+	}
+	return s
+}
+
+func (l *Logger) child(name string, derive func(*zap.Logger) *zap.Logger) *Logger {
+	c := &Logger{parent: l, derive: derive, name: name}
+	c.load()
+	return c
+}
+
+// defaultZapLogger 未调用 Init 时使用的日志器，打印所有级别到控制台
+func defaultZapLogger() *zap.Logger {
+	encoder := withMasking(zapcore.NewJSONEncoder(zap.NewDevelopmentEncoderConfig()), newFieldMasker(nil))
+	core := zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), zapcore.DebugLevel)
+	return zap.New(withLevel(core, globalLevel), zap.AddCaller())
+}
+
+// Default 返回全局日志门面，Init 前后均可使用
+func Default() *Logger {
+	return std
+}
+
+// With 创建带有固定字段的子日志器，字段格式同 Infow
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	return l.child(l.name, func(base *zap.Logger) *zap.Logger {
+		return base.Sugar().With(keysAndValues...).Desugar()
+	})
+}
+
+// WithFields 创建带有固定 zap 字段的子日志器
+func (l *Logger) WithFields(fields ...zapcore.Field) *Logger {
+	return l.child(l.name, func(base *zap.Logger) *zap.Logger {
+		return base.With(fields...)
+	})
+}
+
+// Named 创建带名称的子日志器，可通过 SetNamedLevel 单独调整其级别
+func (l *Logger) Named(name string) *Logger {
+	fullName := name
+	if l.name != "" {
+		fullName = l.name + "." + name
+	}
+	level := getNamedLevel(fullName)
+	return l.child(fullName, func(base *zap.Logger) *zap.Logger {
+		return base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return withLevel(core, level)
+		})).Named(name)
+	})
+}
+
+// Name 获取日志器的完整名称
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Zap 获取原始 zap 日志器（高级用法）
+func (l *Logger) Zap() *zap.Logger {
+	return l.load().base
+}
+
+// Sugar 获取原始 zap SugaredLogger（高级用法）
+func (l *Logger) Sugar() *zap.SugaredLogger {
+	return l.load().base.Sugar()
+}
+
+// Sync 刷新日志缓冲区
+func (l *Logger) Sync() error {
+	return l.load().base.Sync()
+}
+
+// 结构化日志
+func (l *Logger) Debug(msg string, fields ...zapcore.Field) {
+	l.load().method.Debug(msg, fields...)
+}
+
+func (l *Logger) Info(msg string, fields ...zapcore.Field) {
+	l.load().method.Info(msg, fields...)
+}
+
+func (l *Logger) Warn(msg string, fields ...zapcore.Field) {
+	l.load().method.Warn(msg, fields...)
+}
+
+func (l *Logger) Error(msg string, fields ...zapcore.Field) {
+	l.load().method.Error(msg, fields...)
+}
+
+func (l *Logger) Panic(msg string, fields ...zapcore.Field) {
+	l.load().method.Panic(msg, fields...)
+}
+
+func (l *Logger) Fatal(msg string, fields ...zapcore.Field) {
+	l.load().method.Fatal(msg, fields...)
+}
+
+// 带 context 的日志，自动添加 trace_id 等上下文字段
+func (l *Logger) DebugCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.load().method.Debug(msg, append(fields, contextFields(ctx)...)...)
+}
+
+func (l *Logger) InfoCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.load().method.Info(msg, append(fields, contextFields(ctx)...)...)
+}
+
+func (l *Logger) WarnCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.load().method.Warn(msg, append(fields, contextFields(ctx)...)...)
+}
+
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.load().method.Error(msg, append(fields, contextFields(ctx)...)...)
+}
+
+// 格式化日志
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.load().sugar.Debugf(format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.load().sugar.Infof(format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.load().sugar.Warnf(format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.load().sugar.Errorf(format, args...)
+}
+
+// 键值对日志
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.load().sugar.Debugw(msg, keysAndValues...)
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.load().sugar.Infow(msg, keysAndValues...)
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.load().sugar.Warnw(msg, keysAndValues...)
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.load().sugar.Errorw(msg, keysAndValues...)
+}
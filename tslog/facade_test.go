@@ -0,0 +1,107 @@
+package tslog
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// observeStd 将全局门面替换为内存日志器，测试结束后恢复
+func observeStd(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	old := std.state.Load()
+	std.set(zap.New(core, zap.AddCaller()))
+	t.Cleanup(func() { std.state.Store(old) })
+	return logs
+}
+
+func TestFacadeCaller(t *testing.T) {
+	logs := observeStd(t)
+	Info("package function")
+	Default().Info("facade method")
+	Default().Infof("sugar %s", "method")
+	for _, entry := range logs.All() {
+		if file := filepath.Base(entry.Caller.File); file != "facade_test.go" {
+			t.Fatalf("%q caller = %s, want facade_test.go", entry.Message, entry.Caller)
+		}
+	}
+}
+
+func TestFacadeChildFollowsInit(t *testing.T) {
+	observeStd(t)
+	child := Default().With("request_id", "r1").Named("svc")
+	if child.Name() != "svc" {
+		t.Fatalf("name = %q", child.Name())
+	}
+
+	// 根日志器重新初始化后，子日志器重新派生并保留字段
+	core, logs := observer.New(zapcore.DebugLevel)
+	std.set(zap.New(core))
+	child.Info("after init")
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	if entries[0].LoggerName != "svc" || entries[0].ContextMap()["request_id"] != "r1" {
+		t.Fatalf("entry = %+v", entries[0])
+	}
+}
+
+func TestCompatDebugArgs(t *testing.T) {
+	logs := observeStd(t)
+	// 旧用法：任意参数拼接为消息
+	Debug("count", 3)
+	// 新用法：消息 + zap 字段
+	Debug("structured", zap.Int("count", 3))
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if entries[0].Message != "count3" || len(entries[0].Context) != 0 {
+		t.Fatalf("sugared entry = %+v", entries[0])
+	}
+	if entries[1].Message != "structured" || entries[1].ContextMap()["count"] != int64(3) {
+		t.Fatalf("structured entry = %+v", entries[1])
+	}
+}
+
+func TestCompatWith(t *testing.T) {
+	logs := observeStd(t)
+	// 包级 With 保持返回 SugaredLogger
+	var sugar *zap.SugaredLogger = With("service", "auth")
+	sugar.Infow("request", "duration_ms", 150)
+	entry := logs.All()[0]
+	if entry.ContextMap()["service"] != "auth" || entry.ContextMap()["duration_ms"] != int64(150) {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
+
+func TestCompatGormLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	// 旧用法：直接传入 zap 日志器
+	l := New(zap.New(core)).LogMode(gormlogger.Info)
+	l.Info(context.Background(), "from gorm")
+	if logs.Len() != 1 || !strings.HasPrefix(logs.All()[0].Message, "from gorm") {
+		t.Fatalf("entries = %+v", logs.All())
+	}
+
+	stdLogs := observeStd(t)
+	NewGormLogger(nil).Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT 1", 1
+	}, nil)
+	if stdLogs.Len() != 1 {
+		t.Fatalf("gorm logger without zap logger should use the facade, got %d entries", stdLogs.Len())
+	}
+	if Zap() == nil {
+		t.Fatal("compat Zap logger is nil")
+	}
+}
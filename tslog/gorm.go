@@ -8,56 +8,47 @@ import (
 	"runtime"
 	"strings"
 	"time"
-	"zyj.com/golang-study/define"
-	"zyj.com/golang-study/util/ginutil"
 
-	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 )
 
-type ContextFn func(ctx context.Context) []zapcore.Field
-
-// defaultContextFn 默认的 context 字段提取器，提取 trace_id
-func defaultContextFn(ctx context.Context) []zapcore.Field {
-	if ctx == nil {
-		return nil
-	}
-
-	var fields []zapcore.Field
-
-	// 尝试从 context.Value 中获取 trace_id
-	if traceID, ok := ctx.Value(define.HEADER_TRACE_ID_KEY).(string); ok && traceID != "" {
-		fields = append(fields, zap.String("trace_id", traceID))
-	} else if ginCtx, ok := ctx.(*gin.Context); ok {
-		// 尝试从 Gin context 中获取
-		if traceID := ginutil.GetTraceID(ginCtx); traceID != "" {
-			fields = append(fields, zap.String("trace_id", traceID))
-		}
-	}
-
-	return fields
-}
-
+// XLogger gorm 日志适配器，通过 tslog 门面输出
 type XLogger struct {
-	ZapLogger                 *zap.Logger
+	Logger    *Logger     // 输出使用的日志门面，为空时使用 ZapLogger
+	ZapLogger *zap.Logger // 兼容旧代码直接指定 zap 日志器
+
 	LogLevel                  gormlogger.LogLevel
 	SlowThreshold             time.Duration
 	SkipCallerLookup          bool
 	IgnoreRecordNotFoundError bool
 	Context                   ContextFn
+	SlowQuery                 *SlowQueryReporter // 慢查询统计，为空时使用 Config.SlowQuery 创建的全局统计器
 }
 
+// New 由 zap 日志器创建 gorm 日志适配器，zapLogger 为空时使用全局门面
+// 新代码请使用 NewGormLogger，Init 重新初始化后仍会跟随全局门面输出
 func New(zapLogger *zap.Logger) XLogger {
+	l := NewGormLogger(nil)
+	if zapLogger != nil {
+		l.Logger, l.ZapLogger = nil, zapLogger
+	}
+	return l
+}
+
+// NewGormLogger 创建 gorm 日志适配器，logger 为空时使用全局门面
+func NewGormLogger(logger *Logger) XLogger {
+	if logger == nil {
+		logger = Default()
+	}
 	return XLogger{
-		ZapLogger:                 zapLogger,
+		Logger:                    logger,
 		LogLevel:                  gormlogger.Info,
 		SlowThreshold:             100 * time.Millisecond,
 		SkipCallerLookup:          false,
 		IgnoreRecordNotFoundError: false,
-		Context:                   defaultContextFn,
+		Context:                   contextFields,
 	}
 }
 
@@ -67,12 +58,14 @@ func (l XLogger) SetAsDefault() {
 
 func (l XLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
 	return XLogger{
+		Logger:                    l.Logger,
 		ZapLogger:                 l.ZapLogger,
 		SlowThreshold:             l.SlowThreshold,
 		LogLevel:                  level,
 		SkipCallerLookup:          l.SkipCallerLookup,
 		IgnoreRecordNotFoundError: l.IgnoreRecordNotFoundError,
 		Context:                   l.Context,
+		SlowQuery:                 l.SlowQuery,
 	}
 }
 
@@ -101,19 +94,17 @@ func (l XLogger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 	if l.LogLevel <= 0 {
 		return
 	}
-	elapsed := time.Since(begin)
-	logger := l.logger(ctx)
-	switch {
-	case err != nil && l.LogLevel >= gormlogger.Error && (!l.IgnoreRecordNotFoundError || !errors.Is(err, gorm.ErrRecordNotFound)):
-		sql, rows := fc()
-		logger.Error("trace", zap.Error(err), zap.Duration("elapsed", elapsed), zap.Int64("rows", rows), zap.String("sql", sql))
-	case l.SlowThreshold != 0 && elapsed > l.SlowThreshold && l.LogLevel >= gormlogger.Warn:
-		sql, rows := fc()
-		logger.Warn("trace", zap.Duration("elapsed", elapsed), zap.Int64("rows", rows), zap.String("sql", sql))
-	case l.LogLevel >= gormlogger.Info:
-		sql, rows := fc()
-		logger.Debug("trace", zap.Duration("elapsed", elapsed), zap.Int64("rows", rows), zap.String("sql", sql))
+	// 忽略的记录不存在错误不作为错误输出和统计
+	if l.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
 	}
+	sqlTrace{
+		logError:      l.LogLevel >= gormlogger.Error,
+		logSlow:       l.LogLevel >= gormlogger.Warn,
+		logAll:        l.LogLevel >= gormlogger.Info,
+		slowThreshold: l.SlowThreshold,
+		reporter:      l.SlowQuery,
+	}.log(l.logger(ctx).WithOptions(zap.AddCallerSkip(1)), time.Since(begin), fc, err) // 跳过 sqlTrace.log
 }
 
 var (
@@ -122,7 +113,15 @@ var (
 )
 
 func (l XLogger) logger(ctx context.Context) *zap.Logger {
-	logger := l.ZapLogger
+	var logger *zap.Logger
+	switch {
+	case l.Logger != nil:
+		logger = l.Logger.Zap()
+	case l.ZapLogger != nil:
+		logger = l.ZapLogger
+	default:
+		logger = Default().Zap()
+	}
 	if l.Context != nil {
 		fields := l.Context(ctx)
 		logger = logger.With(fields...)
@@ -0,0 +1,143 @@
+package tslog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPBatchSize     = 100
+	defaultHTTPFlushInterval = time.Second
+	defaultHTTPTimeout       = 5 * time.Second
+)
+
+// httpShipper 攒批后以换行分隔的格式 POST 到日志收集服务
+// 发送失败时丢弃该批并输出到标准错误，避免日志堆积拖垮进程
+type httpShipper struct {
+	url       string
+	headers   map[string]string
+	batchSize int
+	client    *http.Client
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	count   int
+	sendMu  sync.Mutex // 保证同一时间只有一个批次在发送
+	flushCh chan struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{} // 后台发送goroutine退出信号
+}
+
+func newHTTPShipper(sink SinkConfig) *httpShipper {
+	s := &httpShipper{
+		url:       sink.URL,
+		headers:   sink.Headers,
+		batchSize: sink.BatchSize,
+		client:    &http.Client{Timeout: sink.Timeout},
+		flushCh:   make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultHTTPBatchSize
+	}
+	if s.client.Timeout <= 0 {
+		s.client.Timeout = defaultHTTPTimeout
+	}
+	interval := sink.FlushInterval
+	if interval <= 0 {
+		interval = defaultHTTPFlushInterval
+	}
+	go s.run(interval)
+	return s
+}
+
+// Write 追加一条日志，攒够一批时通知后台发送
+func (s *httpShipper) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	s.buf.Write(p)
+	s.count++
+	full := s.count >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Sync 立即发送缓冲中的日志
+func (s *httpShipper) Sync() error {
+	return s.flush()
+}
+
+func (s *httpShipper) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flushCh:
+		case <-s.stop:
+			return
+		}
+		if err := s.flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "tslog: %v\n", err)
+		}
+	}
+}
+
+// close 停止后台发送并发送缓冲中剩余的日志
+func (s *httpShipper) close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		if err := s.flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "tslog: %v\n", err)
+		}
+	})
+}
+
+func (s *httpShipper) flush() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if s.count == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	body := bytes.NewBuffer(append([]byte(nil), s.buf.Bytes()...))
+	count := s.count
+	s.buf.Reset()
+	s.count = 0
+	s.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodPost, s.url, body)
+	if err != nil {
+		return fmt.Errorf("failed to ship %d logs: %w", count, err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to ship %d logs: %w", count, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to ship %d logs: status %d", count, resp.StatusCode)
+	}
+	return nil
+}
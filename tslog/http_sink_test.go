@@ -0,0 +1,104 @@
+package tslog
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector 记录收到的请求体
+type collector struct {
+	mu     sync.Mutex
+	bodies []string
+	auth   string
+	got    chan struct{}
+}
+
+func newCollector(t *testing.T, status int) (*collector, *httptest.Server) {
+	c := &collector{got: make(chan struct{}, 10)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.bodies = append(c.bodies, string(body))
+		c.auth = r.Header.Get("Authorization")
+		c.mu.Unlock()
+		w.WriteHeader(status)
+		c.got <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func TestHTTPShipperSync(t *testing.T) {
+	c, server := newCollector(t, http.StatusOK)
+	s := newHTTPShipper(SinkConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer x"}, FlushInterval: time.Hour})
+	s.Write([]byte("{\"msg\":\"a\"}\n"))
+	s.Write([]byte("{\"msg\":\"b\"}\n"))
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	// 缓冲为空时不发送
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.bodies) != 1 || c.bodies[0] != "{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n" || c.auth != "Bearer x" {
+		t.Fatalf("bodies = %q, auth = %q", c.bodies, c.auth)
+	}
+}
+
+func TestHTTPShipperBatchSize(t *testing.T) {
+	c, server := newCollector(t, http.StatusOK)
+	s := newHTTPShipper(SinkConfig{URL: server.URL, BatchSize: 2, FlushInterval: time.Hour})
+	s.Write([]byte("1\n"))
+	s.Write([]byte("2\n"))
+	select {
+	case <-c.got:
+	case <-time.After(time.Second):
+		t.Fatal("full batch not shipped")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.bodies[0] != "1\n2\n" {
+		t.Fatalf("body = %q", c.bodies[0])
+	}
+}
+
+func TestHTTPShipperErrorStatus(t *testing.T) {
+	_, server := newCollector(t, http.StatusServiceUnavailable)
+	s := newHTTPShipper(SinkConfig{URL: server.URL, FlushInterval: time.Hour})
+	s.Write([]byte("1\n"))
+	if err := s.Sync(); err == nil {
+		t.Fatal("expected error for 503")
+	}
+	// 失败的批次被丢弃
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count != 0 || s.buf.Len() != 0 {
+		t.Fatalf("failed batch kept: %d", s.count)
+	}
+}
+
+func TestHTTPShipperClose(t *testing.T) {
+	c, server := newCollector(t, http.StatusOK)
+	s := newHTTPShipper(SinkConfig{URL: server.URL, FlushInterval: time.Hour})
+	s.Write([]byte("last\n"))
+	s.close()
+	s.close()
+
+	select {
+	case <-s.done:
+	default:
+		t.Fatal("run not stopped")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.bodies) != 1 || c.bodies[0] != "last\n" {
+		t.Fatalf("bodies = %q", c.bodies)
+	}
+}
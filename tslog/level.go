@@ -0,0 +1,197 @@
+package tslog
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"zyj.com/golang-study/api"
+	"zyj.com/golang-study/pkg/tserror"
+)
+
+var (
+	// globalLevel 全局日志级别，未初始化前打印所有级别
+	globalLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	// levelSet 全局级别已通过 SetLevel 显式设置，Init 不再用配置覆盖
+	levelSet atomic.Bool
+
+	namedLevelsMu sync.Mutex
+	namedLevels   = map[string]*namedLevel{}
+)
+
+// namedLevel 命名日志器的级别，未单独设置时跟随全局级别
+type namedLevel struct {
+	override atomic.Bool
+	level    zap.AtomicLevel
+}
+
+func (n *namedLevel) Enabled(lvl zapcore.Level) bool {
+	if n.override.Load() {
+		return n.level.Enabled(lvl)
+	}
+	return globalLevel.Enabled(lvl)
+}
+
+func getNamedLevel(name string) *namedLevel {
+	namedLevelsMu.Lock()
+	defer namedLevelsMu.Unlock()
+	n, ok := namedLevels[name]
+	if !ok {
+		n = &namedLevel{level: zap.NewAtomicLevel()}
+		namedLevels[name] = n
+	}
+	return n
+}
+
+// levelCore 用可动态调整的级别过滤日志，底层 core 本身不做级别限制
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func withLevel(core zapcore.Core, level zapcore.LevelEnabler) zapcore.Core {
+	if lc, ok := core.(*levelCore); ok {
+		core = lc.Core
+	}
+	return &levelCore{Core: core, level: level}
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c *levelCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// parseLevel 解析日志级别，无法识别时返回错误
+func parseLevel(level string) (zapcore.Level, error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return lvl, fmt.Errorf("invalid log level: %q", level)
+	}
+	return lvl, nil
+}
+
+// GetLevel 获取全局日志级别
+func GetLevel() string {
+	return globalLevel.String()
+}
+
+// SetLevel 运行时修改全局日志级别
+func SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	globalLevel.SetLevel(lvl)
+	levelSet.Store(true)
+	return nil
+}
+
+// initLevel Init 时应用配置的级别，已通过 SetLevel 设置过则保留
+func initLevel(level string) {
+	if !levelSet.Load() {
+		globalLevel.SetLevel(getZapLevel(level))
+	}
+}
+
+// SetNamedLevel 运行时修改命名日志器的级别，level 为空时恢复跟随全局级别
+func SetNamedLevel(name, level string) error {
+	n := getNamedLevel(name)
+	if level == "" {
+		n.override.Store(false)
+		return nil
+	}
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	n.level.SetLevel(lvl)
+	n.override.Store(true)
+	return nil
+}
+
+// NamedLevels 获取单独设置过级别的命名日志器
+func NamedLevels() map[string]string {
+	namedLevelsMu.Lock()
+	defer namedLevelsMu.Unlock()
+	levels := make(map[string]string)
+	for name, n := range namedLevels {
+		if n.override.Load() {
+			levels[name] = n.level.String()
+		}
+	}
+	return levels
+}
+
+// LevelInfo 日志级别接口的返回值
+type LevelInfo struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers,omitempty"`
+	Names   []string          `json:"names,omitempty"` // 已创建的命名日志器
+}
+
+// LevelRequest 修改日志级别的请求，Name 为空时修改全局级别
+type LevelRequest struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+func currentLevelInfo() LevelInfo {
+	namedLevelsMu.Lock()
+	names := make([]string, 0, len(namedLevels))
+	for name := range namedLevels {
+		names = append(names, name)
+	}
+	namedLevelsMu.Unlock()
+	sort.Strings(names)
+	return LevelInfo{Level: GetLevel(), Loggers: NamedLevels(), Names: names}
+}
+
+// LevelHandler 查询（GET）和修改（PUT）日志级别
+func LevelHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodGet {
+			ctx.JSON(http.StatusOK, api.NewResult(currentLevelInfo(), nil))
+			return
+		}
+		if ctx.Request.Method != http.MethodPut {
+			ctx.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+		var req LevelRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, api.NewResult(nil, tserror.NewBizErrCode(tserror.CodeParamBind, err.Error())))
+			return
+		}
+		var err error
+		if req.Name == "" {
+			err = SetLevel(req.Level)
+		} else {
+			err = SetNamedLevel(req.Name, req.Level)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, api.NewResult(nil, tserror.NewBizErrCode(tserror.CodeParamInvalid, err.Error())))
+			return
+		}
+		Infow("log level changed", "name", req.Name, "level", req.Level)
+		ctx.JSON(http.StatusOK, api.NewResult(currentLevelInfo(), nil))
+	}
+}
@@ -0,0 +1,120 @@
+package tslog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// resetLevels 测试结束后恢复全局级别和命名日志器级别
+func resetLevels(t *testing.T) {
+	t.Helper()
+	old, oldSet := globalLevel.Level(), levelSet.Load()
+	t.Cleanup(func() {
+		globalLevel.SetLevel(old)
+		levelSet.Store(oldSet)
+		for name := range NamedLevels() {
+			SetNamedLevel(name, "")
+		}
+	})
+}
+
+func TestNamedLevel(t *testing.T) {
+	resetLevels(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	root := newLogger(zap.New(withLevel(core, globalLevel)))
+	named := root.Named("level_test")
+
+	if err := SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	named.Info("dropped by global level")
+	// 单独设置的级别优先于全局级别
+	if err := SetNamedLevel("level_test", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	named.Debug("kept by named level")
+	root.Info("dropped by global level")
+	if NamedLevels()["level_test"] != "debug" {
+		t.Fatalf("named levels = %v", NamedLevels())
+	}
+	// 清空后恢复跟随全局级别
+	SetNamedLevel("level_test", "")
+	named.Debug("dropped again")
+
+	if logs.Len() != 1 || logs.All()[0].Message != "kept by named level" {
+		t.Fatalf("entries = %+v", logs.All())
+	}
+}
+
+func TestSetLevelInvalid(t *testing.T) {
+	resetLevels(t)
+	if err := SetLevel("verbose"); err == nil {
+		t.Fatal("SetLevel accepted an invalid level")
+	}
+	if err := SetNamedLevel("level_test", "verbose"); err == nil {
+		t.Fatal("SetNamedLevel accepted an invalid level")
+	}
+}
+
+func TestInitLevelKeepsExplicitLevel(t *testing.T) {
+	resetLevels(t)
+	levelSet.Store(false)
+	initLevel("warn")
+	if GetLevel() != "warn" {
+		t.Fatalf("level = %s, want config level warn", GetLevel())
+	}
+	// 先由配置中心设置级别，之后的 Init 不覆盖
+	if err := SetLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	initLevel("debug")
+	if GetLevel() != "error" {
+		t.Fatalf("level = %s, Init overrode explicit level", GetLevel())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	resetLevels(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Any("/log/level", LevelHandler())
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPut, `{"level":"error"}`); w.Code != http.StatusOK || GetLevel() != "error" {
+		t.Fatalf("put global: %d %s, level %s", w.Code, w.Body, GetLevel())
+	}
+	if w := do(http.MethodPut, `{"name":"svc","level":"debug"}`); w.Code != http.StatusOK {
+		t.Fatalf("put named: %d %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPut, `{"level":"verbose"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("put invalid: %d", w.Code)
+	}
+	if w := do(http.MethodDelete, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("delete: %d", w.Code)
+	}
+
+	w := do(http.MethodGet, "")
+	var resp struct {
+		Data LevelInfo `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Level != "error" || resp.Data.Loggers["svc"] != "debug" {
+		t.Fatalf("get: %s", w.Body)
+	}
+}
@@ -2,89 +2,170 @@ package tslog
 
 import (
 	"context"
-	"os"
-	"zyj.com/golang-study/define"
-	"zyj.com/golang-study/util/ginutil"
 
-	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var (
-	// 打印所有级别的日志
-	lowPriority = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= zapcore.DebugLevel
-	})
-
-	// High-priority output should also go to standard error, and low-priority
-	// output should also go to standard out.
-	consoleDebugging = zapcore.Lock(os.Stdout)
-
-	consoleEncoder = zapcore.NewJSONEncoder(zap.NewDevelopmentEncoderConfig())
+// Zap 获取全局 zap 日志器，替代旧的 Logger 变量，Init 后返回配置的输出
+// Deprecated: 请使用 Default() 获取日志门面
+func Zap() *zap.Logger {
+	return std.Zap()
+}
 
-	// Join the outputs, encoders, and level-handling functions into
-	// zapcore.Cores, then tee the four cores together.
-	core = zapcore.NewTee(
-		// 打印在控制台
-		zapcore.NewCore(consoleEncoder, consoleDebugging, lowPriority),
-	)
+// Debug 兼容旧的 Debug(args...) 用法：参数为 消息+zap 字段 时输出结构化日志，否则拼接参数作为消息
+func Debug(args ...interface{}) {
+	if msg, fields, ok := structuredArgs(args); ok {
+		std.load().method.Debug(msg, fields...)
+		return
+	}
+	std.load().sugar.Debug(args...)
+}
 
-	Logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
-)
+// 全局结构化日志函数
 
 func Info(msg string, fields ...zapcore.Field) {
-	Logger.Info(msg, fields...)
+	std.load().method.Info(msg, fields...)
 }
+
 func Warn(msg string, fields ...zapcore.Field) {
-	Logger.Warn(msg, fields...)
+	std.load().method.Warn(msg, fields...)
 }
+
 func Error(msg string, fields ...zapcore.Field) {
-	Logger.Error(msg, fields...)
+	std.load().method.Error(msg, fields...)
 }
 
-// getTraceIDFromContext 从不同类型的 context 中提取 trace_id
-func getTraceIDFromContext(ctx context.Context) string {
-	if ctx == nil {
-		return ""
+// Panic 兼容旧的 Panic(args...) 用法，参数规则同 Debug
+func Panic(args ...interface{}) {
+	if msg, fields, ok := structuredArgs(args); ok {
+		std.load().method.Panic(msg, fields...)
+		return
 	}
+	std.load().sugar.Panic(args...)
+}
 
-	// 尝试从 context.Value 中获取 trace_id（如果有中间件存储了）
-	if traceID, ok := ctx.Value(define.HEADER_TRACE_ID_KEY).(string); ok && traceID != "" {
-		return traceID
+// Fatal 兼容旧的 Fatal(args...) 用法，参数规则同 Debug
+func Fatal(args ...interface{}) {
+	if msg, fields, ok := structuredArgs(args); ok {
+		std.load().method.Fatal(msg, fields...)
+		return
 	}
+	std.load().sugar.Fatal(args...)
+}
 
-	// 尝试从 Gin context 中获取（如果 context 是从 Gin 传递过来的）
-	if ginCtx, ok := ctx.(*gin.Context); ok {
-		return ginutil.GetTraceID(ginCtx)
+// structuredArgs 参数是否为 消息 + zap 字段
+func structuredArgs(args []interface{}) (string, []zapcore.Field, bool) {
+	if len(args) == 0 {
+		return "", nil, false
+	}
+	msg, ok := args[0].(string)
+	if !ok {
+		return "", nil, false
+	}
+	fields := make([]zapcore.Field, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		field, ok := arg.(zapcore.Field)
+		if !ok {
+			return "", nil, false
+		}
+		fields = append(fields, field)
 	}
+	return msg, fields, true
+}
 
-	return ""
+// DebugCtx 带 context 的 Debug 日志，自动添加 RegisterContextFn 注册的上下文字段
+func DebugCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	std.load().method.Debug(msg, append(fields, contextFields(ctx)...)...)
 }
 
-// InfoCtx 带 context 的 Info 日志，自动添加 trace_id
+// InfoCtx 带 context 的 Info 日志，自动添加 RegisterContextFn 注册的上下文字段
 func InfoCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
-	traceID := getTraceIDFromContext(ctx)
-	if traceID != "" {
-		fields = append(fields, zap.String("trace_id", traceID))
-	}
-	Logger.Info(msg, fields...)
+	std.load().method.Info(msg, append(fields, contextFields(ctx)...)...)
 }
 
-// WarnCtx 带 context 的 Warn 日志，自动添加 trace_id
+// WarnCtx 带 context 的 Warn 日志，自动添加 RegisterContextFn 注册的上下文字段
 func WarnCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
-	traceID := getTraceIDFromContext(ctx)
-	if traceID != "" {
-		fields = append(fields, zap.String("trace_id", traceID))
-	}
-	Logger.Warn(msg, fields...)
+	std.load().method.Warn(msg, append(fields, contextFields(ctx)...)...)
 }
 
-// ErrorCtx 带 context 的 Error 日志，自动添加 trace_id
+// ErrorCtx 带 context 的 Error 日志，自动添加 RegisterContextFn 注册的上下文字段
 func ErrorCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
-	traceID := getTraceIDFromContext(ctx)
-	if traceID != "" {
-		fields = append(fields, zap.String("trace_id", traceID))
+	std.load().method.Error(msg, append(fields, contextFields(ctx)...)...)
+}
+
+// 格式化日志函数
+func Debugf(format string, args ...interface{}) {
+	std.load().sugar.Debugf(format, args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	std.load().sugar.Infof(format, args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	std.load().sugar.Warnf(format, args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	std.load().sugar.Errorf(format, args...)
+}
+
+func Fatalf(format string, args ...interface{}) {
+	std.load().sugar.Fatalf(format, args...)
+}
+
+func Panicf(format string, args ...interface{}) {
+	std.load().sugar.Panicf(format, args...)
+}
+
+// 带字段的日志函数
+func Debugw(msg string, keysAndValues ...interface{}) {
+	std.load().sugar.Debugw(msg, keysAndValues...)
+}
+
+func Infow(msg string, keysAndValues ...interface{}) {
+	std.load().sugar.Infow(msg, keysAndValues...)
+}
+
+func Warnw(msg string, keysAndValues ...interface{}) {
+	std.load().sugar.Warnw(msg, keysAndValues...)
+}
+
+func Errorw(msg string, keysAndValues ...interface{}) {
+	std.load().sugar.Errorw(msg, keysAndValues...)
+}
+
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	std.load().sugar.Fatalw(msg, keysAndValues...)
+}
+
+func Panicw(msg string, keysAndValues ...interface{}) {
+	std.load().sugar.Panicw(msg, keysAndValues...)
+}
+
+// With 创建带有字段的日志器，返回 SugaredLogger 以兼容旧代码，需要门面时使用 Default().With
+func With(args ...interface{}) *zap.SugaredLogger {
+	return std.With(args...).Sugar()
+}
+
+// Sync 刷新日志缓冲区，启用异步写入时会写出缓冲中的全部日志，退出前应调用
+func Sync() error {
+	return std.Sync()
+}
+
+// Close 停止 Init 启动的后台 goroutine（如采样汇总），写出缓冲中的日志并关闭输出目标，进程退出前调用
+func Close() error {
+	err := Sync()
+	fns := closers
+	closers = nil
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
 	}
-	Logger.Error(msg, fields...)
+	return err
+}
+
+// GetLogger 获取原始日志器（高级用法）
+func GetLogger() *zap.SugaredLogger {
+	return std.Sugar()
 }
@@ -0,0 +1,119 @@
+package tslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder 以 key=value 格式输出日志，字段按名称排序
+type logfmtEncoder struct {
+	*zapcore.MapObjectEncoder
+	config zapcore.EncoderConfig
+}
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), config: config}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for key, value := range e.Fields {
+		clone.Fields[key] = value
+	}
+	return &logfmtEncoder{MapObjectEncoder: clone, config: e.config}
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := e.Clone().(*logfmtEncoder)
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	buf := logfmtPool.Get()
+	if e.config.TimeKey != "" {
+		writeLogfmtPair(buf, e.config.TimeKey, entry.Time.Format("2006-01-02T15:04:05.000Z0700"))
+	}
+	if e.config.LevelKey != "" {
+		writeLogfmtPair(buf, e.config.LevelKey, entry.Level.CapitalString())
+	}
+	if e.config.NameKey != "" && entry.LoggerName != "" {
+		writeLogfmtPair(buf, e.config.NameKey, entry.LoggerName)
+	}
+	if e.config.CallerKey != "" && entry.Caller.Defined {
+		writeLogfmtPair(buf, e.config.CallerKey, entry.Caller.TrimmedPath())
+	}
+	if e.config.MessageKey != "" {
+		writeLogfmtPair(buf, e.config.MessageKey, entry.Message)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeLogfmtPair(buf, key, formatLogfmtValue(enc.Fields[key]))
+	}
+
+	if e.config.StacktraceKey != "" && entry.Stack != "" {
+		writeLogfmtPair(buf, e.config.StacktraceKey, entry.Stack)
+	}
+	lineEnding := e.config.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	buf.AppendString(lineEnding)
+	return buf, nil
+}
+
+func writeLogfmtPair(buf *buffer.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.AppendByte(' ')
+	}
+	buf.AppendString(key)
+	buf.AppendByte('=')
+	if needsQuote(value) {
+		buf.AppendString(strconv.Quote(value))
+	} else {
+		buf.AppendString(value)
+	}
+}
+
+func needsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	return strings.ContainsFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+	})
+}
+
+func formatLogfmtValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,51 @@
+package tslog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	enc := newLogfmtEncoder(newEncoderConfig())
+	enc.AddString("service", "auth")
+	entry := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC),
+		LoggerName: "svc",
+		Message:    "login failed",
+	}
+	buf, err := enc.EncodeEntry(entry, []zapcore.Field{
+		zap.String("user", "a=b"),
+		zap.Int("attempt", 2),
+		zap.Duration("cost", 1500*time.Millisecond),
+		zap.Strings("roles", []string{"admin"}),
+		zap.Error(errors.New("bad password")),
+		zap.String("empty", ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 固定字段在前，其余字段按名称排序，含空格、等号或为空的值加引号
+	want := `time=2024-01-02T03:04:05.006Z level=WARN logger=svc msg="login failed" ` +
+		`attempt=2 cost=1.5s empty="" error="bad password" roles="[\"admin\"]" service=auth user="a=b"` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestLogfmtEncoderCloneIsolated(t *testing.T) {
+	enc := newLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	clone := enc.Clone()
+	clone.AddString("child", "1")
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "root"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "msg=root\n" {
+		t.Fatalf("clone fields leaked into parent: %q", got)
+	}
+}
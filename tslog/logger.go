@@ -1,4 +1,3 @@
-// logger/logger.go
 package tslog
 
 import (
@@ -12,24 +11,42 @@ import (
 
 // 全局变量
 var (
-	globalLogger *zap.SugaredLogger
-	once         sync.Once
+	// std 默认日志门面，未初始化前输出到控制台，Init 后替换为配置的输出
+	std  = newLogger(defaultZapLogger())
+	once sync.Once
+	// closers Init 启动的后台 goroutine 及输出目标的关闭函数，由 Close 按注册的逆序调用：
+	// 先停止会产生日志的组件（慢查询报告、采样汇总），最后关闭输出目标
+	closers []func()
 )
 
 // Init 初始化日志（简单版本）
 func Init(logPath string, debug bool) error {
-	var err error
-	once.Do(func() {
-		globalLogger, err = createLogger(logPath, debug)
-	})
-	return err
+	config := DefaultConfig()
+	config.LogPath = logPath
+	if debug {
+		config.Level = "debug"
+	}
+	return InitWithConfig(config)
 }
 
-// InitWithConfig 初始化日志（带配置版本）
+// InitWithConfig 初始化日志（带配置版本），只有第一次调用生效
+// 之前已通过 SetLevel 设置过全局级别（如 config.Init 应用配置中心的级别）时，不使用 config.Level 覆盖
 func InitWithConfig(config *Config) error {
 	var err error
 	once.Do(func() {
-		globalLogger, err = createLoggerWithConfig(config)
+		var logger *zap.Logger
+		var loggerClosers []func()
+		if logger, loggerClosers, err = createLoggerWithConfig(config); err == nil {
+			closers = append(closers, loggerClosers...)
+			initLevel(config.Level)
+			std.set(logger)
+			if config.SlowQuery != nil {
+				// 最后注册，Close 时先于输出目标关闭，保证最后一个周期的报告能写出
+				reporter := NewSlowQueryReporter(*config.SlowQuery, nil)
+				globalSlowQuery.Store(reporter)
+				closers = append(closers, reporter.Close)
+			}
+		}
 	})
 	return err
 }
@@ -42,6 +59,12 @@ type Config struct {
 	MaxBackups int    // 保留的旧日志文件最大个数
 	MaxAge     int    // 保留旧日志文件的最大天数
 	Compress   bool   // 是否压缩旧日志文件
+
+	Sinks     []SinkConfig     // 输出目标，为空时输出到控制台和 LogPath
+	Sampling  *SamplingConfig  // 采样配置，为空时不采样
+	Masking   *MaskingConfig   // 脱敏配置，为空时使用 DefaultMaskRules
+	SlowQuery *SlowQueryConfig // 慢查询统计配置，为空时不统计
+	Async     *AsyncConfig     // 异步写入配置，为空时同步写入
 }
 
 // DefaultConfig 返回默认配置
@@ -55,93 +78,84 @@ func DefaultConfig() *Config {
 	}
 }
 
-// createLogger 创建日志器（简单版本）
-func createLogger(logPath string, debug bool) (*zap.SugaredLogger, error) {
-	config := DefaultConfig()
-	config.LogPath = logPath
-	if debug {
-		config.Level = "debug"
-	}
-	return createLoggerWithConfig(config)
-}
-
-// createLoggerWithConfig 创建日志器（配置版本）
-func createLoggerWithConfig(config *Config) (*zap.SugaredLogger, error) {
-	// 设置日志级别
-	level := getZapLevel(config.Level)
-
-	// 创建编码器配置
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        "time",
-		LevelKey:       "level",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.CapitalLevelEncoder,    // 大写级别编码
-		EncodeTime:     zapcore.ISO8601TimeEncoder,     // ISO8601 时间格式
-		EncodeDuration: zapcore.SecondsDurationEncoder, // 秒为单位
-		EncodeCaller:   zapcore.ShortCallerEncoder,     // 短路径调用者
-	}
-
-	// 创建编码器
-	var encoder zapcore.Encoder
-	if config.Level == "debug" {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig) // 开发环境用控制台格式
-	} else {
-		encoder = zapcore.NewJSONEncoder(encoderConfig) // 生产环境用JSON格式
+// createLoggerWithConfig 创建日志器（配置版本），同时返回输出目标及采样的关闭函数
+// 某个输出目标创建失败时关闭已创建的输出目标
+func createLoggerWithConfig(config *Config) (*zap.Logger, []func(), error) {
+	var closeFns []func()
+	closeAll := func() {
+		for i := len(closeFns) - 1; i >= 0; i-- {
+			closeFns[i]()
+		}
 	}
 
-	// 创建多个写入器
+	// 每个输出目标一个 core，编码前统一脱敏
+	masker := newFieldMasker(config.Masking)
 	cores := []zapcore.Core{}
-
-	// 控制台输出（始终启用）
-	consoleCore := zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), level)
-	cores = append(cores, consoleCore)
-
-	// 文件输出（如果配置了日志路径）
-	if config.LogPath != "" {
-		fileCore, err := createFileCore(encoder, level, config)
+	for _, sink := range config.sinks() {
+		sinkCore, closeFn, err := newSinkCore(config, sink, masker)
 		if err != nil {
-			return nil, err
+			closeAll()
+			return nil, nil, err
+		}
+		cores = append(cores, sinkCore)
+		if closeFn != nil {
+			closeFns = append(closeFns, closeFn)
 		}
-		cores = append(cores, fileCore)
 	}
 
-	// 创建核心
+	// 创建核心，级别由 globalLevel 或命名日志器级别统一过滤
 	core := zapcore.NewTee(cores...)
+	if config.Sampling != nil {
+		sampling := newSamplingCore(core, config.Sampling)
+		closeFns = append(closeFns, sampling.sampler.close)
+		core = sampling
+	}
+	core = withLevel(core, globalLevel)
 
-	// 创建日志器
-	logger := zap.New(core,
-		zap.AddCaller(),      // 添加调用者信息
-		zap.AddCallerSkip(1), // 跳过一层调用栈
-	)
+	activeMasker.Store(masker)
 
-	return logger.Sugar(), nil
+	// 创建日志器，调用栈跳过由门面负责
+	return zap.New(core, zap.AddCaller()), closeFns, nil
 }
 
-// createFileCore 创建文件日志核心
-func createFileCore(encoder zapcore.Encoder, level zapcore.LevelEnabler, config *Config) (zapcore.Core, error) {
+// sinks 返回配置的输出目标，未配置 Sinks 时为控制台加可选的 LogPath 文件
+func (c *Config) sinks() []SinkConfig {
+	if len(c.Sinks) > 0 {
+		return c.Sinks
+	}
+	sinks := []SinkConfig{{Type: SinkStdout}}
+	if c.LogPath != "" {
+		sinks = append(sinks, SinkConfig{Type: SinkFile, Path: c.LogPath})
+	}
+	return sinks
+}
+
+// createFileWriter 创建滚动文件写入器及其关闭函数，滚动参数未配置时使用 Config 中的值
+func createFileWriter(config *Config, sink SinkConfig) (zapcore.WriteSyncer, func(), error) {
 	// 确保日志目录存在
-	if err := os.MkdirAll(filepath.Dir(config.LogPath), 0755); err != nil {
-		return nil, err
+	if err := os.MkdirAll(filepath.Dir(sink.Path), 0755); err != nil {
+		return nil, nil, err
 	}
 
 	// 创建 lumberjack 日志滚动器
 	lumberjackLogger := &lumberjack.Logger{
-		Filename:   config.LogPath,
-		MaxSize:    config.MaxSize,    // 单个文件最大大小(MB)
-		MaxBackups: config.MaxBackups, // 保留的旧文件最大个数
-		MaxAge:     config.MaxAge,     // 保留旧文件的最大天数
-		Compress:   config.Compress,   // 是否压缩旧文件
-		LocalTime:  true,              // 使用本地时间
+		Filename:   sink.Path,
+		MaxSize:    orDefault(sink.MaxSize, config.MaxSize),       // 单个文件最大大小(MB)
+		MaxBackups: orDefault(sink.MaxBackups, config.MaxBackups), // 保留的旧文件最大个数
+		MaxAge:     orDefault(sink.MaxAge, config.MaxAge),         // 保留旧文件的最大天数
+		Compress:   config.Compress,                               // 是否压缩旧文件
+		LocalTime:  true,                                          // 使用本地时间
 	}
 
 	// 创建文件写入器
-	fileWriteSyncer := zapcore.AddSync(lumberjackLogger)
+	return zapcore.AddSync(lumberjackLogger), func() { lumberjackLogger.Close() }, nil
+}
 
-	return zapcore.NewCore(encoder, fileWriteSyncer, level), nil
+func orDefault(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
 }
 
 // getZapLevel 将字符串级别转换为 zapcore.Level
@@ -164,96 +178,6 @@ func getZapLevel(level string) zapcore.Level {
 	}
 }
 
-// 全局日志函数
-func Debug(args ...interface{}) {
-	globalLogger.Debug(args...)
-}
-
-//func Info(args ...interface{}) {
-//	globalLogger.Info(args...)
-//}
-//
-//func Warn(args ...interface{}) {
-//	globalLogger.Warn(args...)
-//}
-//
-//func Error(args ...interface{}) {
-//	globalLogger.Error(args...)
-//}
-
-func Fatal(args ...interface{}) {
-	globalLogger.Fatal(args...)
-}
-
-func Panic(args ...interface{}) {
-	globalLogger.Panic(args...)
-}
-
-// 格式化日志函数
-func Debugf(format string, args ...interface{}) {
-	globalLogger.Debugf(format, args...)
-}
-
-func Infof(format string, args ...interface{}) {
-	globalLogger.Infof(format, args...)
-}
-
-func Warnf(format string, args ...interface{}) {
-	globalLogger.Warnf(format, args...)
-}
-
-func Errorf(format string, args ...interface{}) {
-	globalLogger.Errorf(format, args...)
-}
-
-func Fatalf(format string, args ...interface{}) {
-	globalLogger.Fatalf(format, args...)
-}
-
-func Panicf(format string, args ...interface{}) {
-	globalLogger.Panicf(format, args...)
-}
-
-// 带字段的日志函数
-func Debugw(msg string, keysAndValues ...interface{}) {
-	globalLogger.Debugw(msg, keysAndValues...)
-}
-
-func Infow(msg string, keysAndValues ...interface{}) {
-	globalLogger.Infow(msg, keysAndValues...)
-}
-
-func Warnw(msg string, keysAndValues ...interface{}) {
-	globalLogger.Warnw(msg, keysAndValues...)
-}
-
-func Errorw(msg string, keysAndValues ...interface{}) {
-	globalLogger.Errorw(msg, keysAndValues...)
-}
-
-func Fatalw(msg string, keysAndValues ...interface{}) {
-	globalLogger.Fatalw(msg, keysAndValues...)
-}
-
-func Panicw(msg string, keysAndValues ...interface{}) {
-	globalLogger.Panicw(msg, keysAndValues...)
-}
-
-// 创建带有字段的日志器
-func With(args ...interface{}) *zap.SugaredLogger {
-	return globalLogger.With(args...)
-}
-
-// Sync 刷新日志缓冲区
-func Sync() error {
-	return globalLogger.Sync()
-}
-
-// GetLogger 获取原始日志器（高级用法）
-func GetLogger() *zap.SugaredLogger {
-	return globalLogger
-}
-
 // 便捷初始化函数
 func InitDevelopment() error {
 	return Init("", true)
@@ -0,0 +1,475 @@
+package tslog
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"zyj.com/golang-study/util/strutil"
+)
+
+// 内置脱敏方式
+const (
+	MaskAll   = "all"   // 全部替换为 ******
+	MaskEmail = "email" // z***@163.com
+	MaskPhone = "phone" // 138****5678
+	MaskAuto  = "auto"  // 识别邮箱或手机号后脱敏，其他原样输出
+)
+
+const maskPlaceholder = "******"
+
+// Masker 脱敏函数
+type Masker func(value string) string
+
+var (
+	maskersMu sync.RWMutex
+	maskers   = map[string]Masker{
+		MaskAll:   maskAll,
+		MaskEmail: maskEmail,
+		MaskPhone: maskPhone,
+		MaskAuto:  maskAuto,
+	}
+)
+
+// DefaultMaskRules 默认按字段名脱敏的规则，字段名不区分大小写
+var DefaultMaskRules = map[string]string{
+	"pwd":           MaskAll,
+	"password":      MaskAll,
+	"passwd":        MaskAll,
+	"secret":        MaskAll,
+	"token":         MaskAll,
+	"authorization": MaskAll,
+	"email":         MaskEmail,
+	"phone":         MaskPhone,
+	"mobile":        MaskPhone,
+}
+
+// MaskingConfig 日志脱敏配置
+type MaskingConfig struct {
+	Disabled   bool              // 关闭脱敏
+	Rules      map[string]string // 字段名到脱敏方式的映射，与 DefaultMaskRules 合并
+	AutoDetect bool              // 对所有字符串字段识别邮箱和手机号
+}
+
+func maskAll(string) string {
+	return maskPlaceholder
+}
+
+func maskEmail(value string) string {
+	at := strings.LastIndex(value, "@")
+	if at <= 0 {
+		return maskAll(value)
+	}
+	first, _ := utf8.DecodeRuneInString(value)
+	return string(first) + "***" + value[at:]
+}
+
+func maskPhone(value string) string {
+	numbers := strutil.ExtractNumbers(value)
+	if len(numbers) < 7 {
+		return maskAll(value)
+	}
+	return numbers[:3] + "****" + numbers[len(numbers)-4:]
+}
+
+func maskAuto(value string) string {
+	switch {
+	case strutil.IsEmail(value):
+		return maskEmail(value)
+	case strutil.IsPhone(value):
+		return maskPhone(value)
+	default:
+		return value
+	}
+}
+
+// RegisterMasker 注册自定义脱敏方式，可在规则和 log:"mask=name" 标签中使用
+func RegisterMasker(name string, masker Masker) {
+	maskersMu.Lock()
+	defer maskersMu.Unlock()
+	maskers[name] = masker
+}
+
+func lookupMasker(name string) (Masker, bool) {
+	maskersMu.RLock()
+	defer maskersMu.RUnlock()
+	masker, ok := maskers[name]
+	return masker, ok
+}
+
+// fieldMasker 按字段名规则和结构体标签脱敏
+type fieldMasker struct {
+	rules      map[string]Masker
+	autoDetect bool
+}
+
+// activeMasker 当前生效的脱敏器，供 XLogger 处理 SQL 使用
+var activeMasker atomic.Pointer[fieldMasker]
+
+func init() {
+	activeMasker.Store(newFieldMasker(nil))
+}
+
+// newFieldMasker 创建脱敏器，config 为空时使用默认规则，关闭脱敏时返回 nil
+func newFieldMasker(config *MaskingConfig) *fieldMasker {
+	if config != nil && config.Disabled {
+		return nil
+	}
+	m := &fieldMasker{rules: make(map[string]Masker)}
+	for field, name := range DefaultMaskRules {
+		m.rules[field], _ = lookupMasker(name)
+	}
+	if config != nil {
+		m.autoDetect = config.AutoDetect
+		for field, name := range config.Rules {
+			if masker, ok := lookupMasker(name); ok {
+				m.rules[strings.ToLower(field)] = masker
+			}
+		}
+	}
+	return m
+}
+
+func (m *fieldMasker) ruleFor(key string) Masker {
+	return m.rules[strings.ToLower(key)]
+}
+
+// maskString 按字段名脱敏字符串
+func (m *fieldMasker) maskString(key, value string) string {
+	if masker := m.ruleFor(key); masker != nil {
+		return masker(value)
+	}
+	if m.autoDetect {
+		return maskAuto(value)
+	}
+	return value
+}
+
+// maskFields 返回脱敏后的字段，未改动时返回原切片
+func (m *fieldMasker) maskFields(fields []zapcore.Field) []zapcore.Field {
+	var masked []zapcore.Field
+	for i, field := range fields {
+		replaced, ok := m.maskField(field)
+		if !ok {
+			continue
+		}
+		if masked == nil {
+			masked = append([]zapcore.Field(nil), fields...)
+		}
+		masked[i] = replaced
+	}
+	if masked == nil {
+		return fields
+	}
+	return masked
+}
+
+func (m *fieldMasker) maskField(field zapcore.Field) (zapcore.Field, bool) {
+	switch field.Type {
+	case zapcore.StringType:
+		if value := m.maskString(field.Key, field.String); value != field.String {
+			return zap.String(field.Key, value), true
+		}
+	case zapcore.ReflectType:
+		if value, ok := m.maskValue(field.Key, field.Interface); ok {
+			return zap.Reflect(field.Key, value), true
+		}
+	case zapcore.ObjectMarshalerType:
+		return zap.Object(field.Key, maskedObject{field.Interface.(zapcore.ObjectMarshaler), m}), true
+	case zapcore.ArrayMarshalerType:
+		return zap.Array(field.Key, maskedArray{field.Interface.(zapcore.ArrayMarshaler), m, field.Key}), true
+	}
+	return field, false
+}
+
+// maskValue 脱敏任意值，结构体在副本上修改，不影响调用方的数据
+func (m *fieldMasker) maskValue(key string, value interface{}) (interface{}, bool) {
+	if s, ok := value.(string); ok {
+		masked := m.maskString(key, s)
+		return masked, masked != s
+	}
+	if value == nil {
+		return value, false
+	}
+	masked, changed := m.maskReflect(reflect.ValueOf(value), 0)
+	if !changed {
+		return value, false
+	}
+	return masked.Interface(), true
+}
+
+const maxMaskDepth = 5
+
+// maskReflect 递归复制并脱敏结构体、指针、切片、map 和 interface
+func (m *fieldMasker) maskReflect(v reflect.Value, depth int) (reflect.Value, bool) {
+	if depth > maxMaskDepth {
+		return v, false
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := m.maskReflect(v.Elem(), depth+1)
+		if !changed {
+			return v, false
+		}
+		ptr := reflect.New(elem.Type())
+		ptr.Elem().Set(elem)
+		return ptr, true
+	case reflect.Struct:
+		return m.maskStruct(v, depth)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false
+		}
+		var copied reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elem, changed := m.maskReflect(v.Index(i), depth+1)
+			if !changed {
+				continue
+			}
+			if !copied.IsValid() {
+				copied = copyIndexable(v)
+			}
+			copied.Index(i).Set(elem)
+		}
+		if copied.IsValid() {
+			return copied, true
+		}
+	case reflect.Map:
+		return m.maskMap(v, depth)
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := m.maskReflect(v.Elem(), depth+1)
+		if !changed {
+			return v, false
+		}
+		wrapped := reflect.New(v.Type()).Elem()
+		wrapped.Set(elem)
+		return wrapped, true
+	}
+	return v, false
+}
+
+// maskNamed 用 masker 脱敏字符串（含 interface 中的字符串），masker 为空时按 autoDetect 处理，其他类型递归处理
+func (m *fieldMasker) maskNamed(masker Masker, v reflect.Value, depth int) (reflect.Value, bool) {
+	s := v
+	if s.Kind() == reflect.Interface && !s.IsNil() {
+		s = s.Elem()
+	}
+	if s.Kind() != reflect.String {
+		return m.maskReflect(v, depth)
+	}
+	if masker == nil {
+		if !m.autoDetect {
+			return v, false
+		}
+		masker = maskAuto
+	}
+	masked := masker(s.String())
+	if masked == s.String() {
+		return v, false
+	}
+	return reflect.ValueOf(masked).Convert(s.Type()), true
+}
+
+// maskMap 按 key 名称脱敏 map 的值，如 gin.H{"pwd": "123"}
+func (m *fieldMasker) maskMap(v reflect.Value, depth int) (reflect.Value, bool) {
+	if v.IsNil() {
+		return v, false
+	}
+	var copied reflect.Value
+	iter := v.MapRange()
+	for iter.Next() {
+		var masker Masker
+		if iter.Key().Kind() == reflect.String {
+			masker = m.ruleFor(iter.Key().String())
+		}
+		elem, changed := m.maskNamed(masker, iter.Value(), depth+1)
+		if !changed {
+			continue
+		}
+		if !copied.IsValid() {
+			copied = reflect.MakeMapWithSize(v.Type(), v.Len())
+			for src := v.MapRange(); src.Next(); {
+				copied.SetMapIndex(src.Key(), src.Value())
+			}
+		}
+		copied.SetMapIndex(iter.Key(), elem)
+	}
+	if copied.IsValid() {
+		return copied, true
+	}
+	return v, false
+}
+
+func copyIndexable(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Slice {
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+		return copied
+	}
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	return copied
+}
+
+func (m *fieldMasker) maskStruct(v reflect.Value, depth int) (reflect.Value, bool) {
+	t := v.Type()
+	var copied reflect.Value
+	set := func(i int, value reflect.Value) {
+		if !copied.IsValid() {
+			copied = reflect.New(t).Elem()
+			copied.Set(v)
+		}
+		copied.Field(i).Set(value)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if masked, changed := m.maskNamed(m.structFieldMasker(sf), v.Field(i), depth+1); changed {
+			set(i, masked)
+		}
+	}
+	if copied.IsValid() {
+		return copied, true
+	}
+	return v, false
+}
+
+// structFieldMasker 优先使用 log 标签，其次按 json 名称和字段名匹配规则
+// log:"mask" 使用 MaskAll，log:"mask=email" 使用指定的脱敏方式
+func (m *fieldMasker) structFieldMasker(sf reflect.StructField) Masker {
+	if tag, ok := sf.Tag.Lookup("log"); ok {
+		if tag == "mask" {
+			return maskAll
+		}
+		if name, found := strings.CutPrefix(tag, "mask="); found {
+			if masker, ok := lookupMasker(name); ok {
+				return masker
+			}
+			return maskAll
+		}
+	}
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		if masker := m.ruleFor(name); masker != nil {
+			return masker
+		}
+	}
+	return m.ruleFor(sf.Name)
+}
+
+// maskingEncoder 在编码前对字段脱敏的编码器包装
+type maskingEncoder struct {
+	zapcore.Encoder
+	masker *fieldMasker
+}
+
+// withMasking 为编码器增加脱敏，masker 为空时原样返回
+func withMasking(encoder zapcore.Encoder, masker *fieldMasker) zapcore.Encoder {
+	if masker == nil {
+		return encoder
+	}
+	return &maskingEncoder{Encoder: encoder, masker: masker}
+}
+
+func (e *maskingEncoder) AddString(key, value string) {
+	e.Encoder.AddString(key, e.masker.maskString(key, value))
+}
+
+func (e *maskingEncoder) AddReflected(key string, value interface{}) error {
+	masked, _ := e.masker.maskValue(key, value)
+	return e.Encoder.AddReflected(key, masked)
+}
+
+func (e *maskingEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	return e.Encoder.AddObject(key, maskedObject{marshaler, e.masker})
+}
+
+func (e *maskingEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	return e.Encoder.AddArray(key, maskedArray{marshaler, e.masker, key})
+}
+
+func (e *maskingEncoder) Clone() zapcore.Encoder {
+	return &maskingEncoder{Encoder: e.Encoder.Clone(), masker: e.masker}
+}
+
+func (e *maskingEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return e.Encoder.EncodeEntry(entry, e.masker.maskFields(fields))
+}
+
+// maskedObject 对 ObjectMarshaler 写出的字段脱敏，嵌套的对象和数组同样处理
+type maskedObject struct {
+	zapcore.ObjectMarshaler
+	masker *fieldMasker
+}
+
+func (o maskedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(&maskingObjectEncoder{ObjectEncoder: enc, masker: o.masker})
+}
+
+// maskedArray 对 ArrayMarshaler 写出的元素脱敏，字符串元素按数组字段名处理
+type maskedArray struct {
+	zapcore.ArrayMarshaler
+	masker *fieldMasker
+	key    string
+}
+
+func (a maskedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(&maskingArrayEncoder{ArrayEncoder: enc, masker: a.masker, key: a.key})
+}
+
+type maskingObjectEncoder struct {
+	zapcore.ObjectEncoder
+	masker *fieldMasker
+}
+
+func (e *maskingObjectEncoder) AddString(key, value string) {
+	e.ObjectEncoder.AddString(key, e.masker.maskString(key, value))
+}
+
+func (e *maskingObjectEncoder) AddReflected(key string, value interface{}) error {
+	masked, _ := e.masker.maskValue(key, value)
+	return e.ObjectEncoder.AddReflected(key, masked)
+}
+
+func (e *maskingObjectEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	return e.ObjectEncoder.AddObject(key, maskedObject{marshaler, e.masker})
+}
+
+func (e *maskingObjectEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	return e.ObjectEncoder.AddArray(key, maskedArray{marshaler, e.masker, key})
+}
+
+type maskingArrayEncoder struct {
+	zapcore.ArrayEncoder
+	masker *fieldMasker
+	key    string
+}
+
+func (e *maskingArrayEncoder) AppendString(value string) {
+	e.ArrayEncoder.AppendString(e.masker.maskString(e.key, value))
+}
+
+func (e *maskingArrayEncoder) AppendReflected(value interface{}) error {
+	masked, _ := e.masker.maskValue(e.key, value)
+	return e.ArrayEncoder.AppendReflected(masked)
+}
+
+func (e *maskingArrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(maskedObject{marshaler, e.masker})
+}
+
+func (e *maskingArrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(maskedArray{marshaler, e.masker, e.key})
+}
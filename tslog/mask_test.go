@@ -0,0 +1,199 @@
+package tslog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type maskedUser struct {
+	Name     string
+	Password string `json:"password"`
+	Mail     string `json:"email"`
+	Card     string `log:"mask"`
+	Phone    string `log:"mask=phone"`
+	Extra    interface{}
+	Profile  *maskedProfile
+}
+
+type maskedProfile struct {
+	Token string
+}
+
+// loginObject 通过 ObjectMarshaler 输出的日志对象
+type loginObject struct {
+	user string
+	pwd  string
+}
+
+func (o loginObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", o.user)
+	enc.AddString("pwd", o.pwd)
+	return enc.AddObject("nested", loginNested{o.pwd})
+}
+
+type loginNested struct{ token string }
+
+func (o loginNested) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("token", o.token)
+	return nil
+}
+
+// encodeMasked 用默认规则脱敏并以 JSON 编码
+func encodeMasked(t *testing.T, config *MaskingConfig, fields ...zapcore.Field) string {
+	t.Helper()
+	enc := withMasking(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), newFieldMasker(config))
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "m"}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func TestMaskFunctions(t *testing.T) {
+	tests := []struct {
+		masker Masker
+		in     string
+		want   string
+	}{
+		{maskAll, "secret", maskPlaceholder},
+		{maskEmail, "zhang@163.com", "z***@163.com"},
+		{maskEmail, "invalid", maskPlaceholder},
+		{maskPhone, "138-1234-5678", "138****5678"},
+		{maskPhone, "12345", maskPlaceholder},
+		{maskAuto, "zhang@163.com", "z***@163.com"},
+		{maskAuto, "13812345678", "138****5678"},
+		{maskAuto, "hello", "hello"},
+	}
+	for _, tt := range tests {
+		if got := tt.masker(tt.in); got != tt.want {
+			t.Errorf("mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaskStringFields(t *testing.T) {
+	got := encodeMasked(t, &MaskingConfig{Rules: map[string]string{"IDCard": MaskAll}},
+		zap.String("Password", "123456"),
+		zap.String("email", "zhang@163.com"),
+		zap.String("idcard", "110101"),
+		zap.String("note", "13812345678"),
+	)
+	want := `{"msg":"m","Password":"******","email":"z***@163.com","idcard":"******","note":"13812345678"}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+	// 开启 AutoDetect 后未配置规则的字段也识别手机号
+	if got := encodeMasked(t, &MaskingConfig{AutoDetect: true}, zap.String("note", "13812345678")); !strings.Contains(got, "138****5678") {
+		t.Fatalf("auto detect: %s", got)
+	}
+	if newFieldMasker(&MaskingConfig{Disabled: true}) != nil {
+		t.Fatal("disabled masking should return nil masker")
+	}
+}
+
+func TestMaskStruct(t *testing.T) {
+	user := &maskedUser{
+		Name:     "zhang",
+		Password: "123456",
+		Mail:     "zhang@163.com",
+		Card:     "6222",
+		Phone:    "13812345678",
+		Extra:    map[string]interface{}{"secret": "s"},
+		Profile:  &maskedProfile{Token: "t"},
+	}
+	got := encodeMasked(t, nil, zap.Any("user", user))
+	for _, want := range []string{
+		`"Name":"zhang"`, `"password":"******"`, `"email":"z***@163.com"`, `"Card":"******"`,
+		`"Phone":"138****5678"`, `"Extra":{"secret":"******"}`, `"Profile":{"Token":"******"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("%s not in %s", want, got)
+		}
+	}
+	// 在副本上脱敏，不修改调用方的数据
+	if user.Password != "123456" || user.Profile.Token != "t" || user.Extra.(map[string]interface{})["secret"] != "s" {
+		t.Fatalf("original modified: %+v", user)
+	}
+}
+
+func TestMaskMapAndInterface(t *testing.T) {
+	h := gin.H{"pwd": "123", "user": "zhang", "list": []interface{}{gin.H{"token": "t"}}}
+	got := encodeMasked(t, nil,
+		zap.Any("body", h),
+		zap.Any("headers", map[string]string{"Authorization": "Bearer x"}),
+		zap.Any("extra", struct{ Secret interface{} }{"s"}),
+	)
+	for _, want := range []string{
+		`"pwd":"******"`, `"user":"zhang"`, `"list":[{"token":"******"}]`,
+		`"Authorization":"******"`, `"extra":{"Secret":"******"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("%s not in %s", want, got)
+		}
+	}
+	if h["pwd"] != "123" || h["list"].([]interface{})[0].(gin.H)["token"] != "t" {
+		t.Fatalf("original modified: %v", h)
+	}
+}
+
+func TestMaskObjectMarshaler(t *testing.T) {
+	obj := loginObject{user: "zhang", pwd: "123456"}
+	got := encodeMasked(t, nil,
+		zap.Object("login", obj),
+		zap.Objects("logins", []loginObject{obj}),
+		zap.Strings("email", []string{"zhang@163.com"}),
+	)
+	want := `{"msg":"m","login":{"user":"zhang","pwd":"******","nested":{"token":"******"}},` +
+		`"logins":[{"user":"zhang","pwd":"******","nested":{"token":"******"}}],"email":["z***@163.com"]}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	// With 附加的对象字段同样脱敏
+	enc := withMasking(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), newFieldMasker(nil))
+	var sb strings.Builder
+	zap.New(zapcore.NewCore(enc, zapcore.AddSync(&sb), zapcore.DebugLevel)).With(zap.Object("login", obj)).Info("m")
+	if !strings.Contains(sb.String(), `"pwd":"******"`) {
+		t.Fatalf("With object not masked: %s", sb.String())
+	}
+}
+
+func TestRegisterMaskerConcurrent(t *testing.T) {
+	t.Cleanup(func() {
+		maskersMu.Lock()
+		delete(maskers, "last4")
+		maskersMu.Unlock()
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterMasker("last4", func(value string) string {
+				if len(value) <= 4 {
+					return maskPlaceholder
+				}
+				return "****" + value[len(value)-4:]
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			newFieldMasker(&MaskingConfig{Rules: map[string]string{"card": "last4"}})
+		}()
+	}
+	wg.Wait()
+
+	type card struct {
+		No string `log:"mask=last4"`
+	}
+	got := encodeMasked(t, &MaskingConfig{Rules: map[string]string{"card_no": "last4"}},
+		zap.String("card_no", "6222000011112222"), zap.Any("card", card{"6222000033334444"}))
+	if !strings.Contains(got, `"card_no":"****2222"`) || !strings.Contains(got, `"No":"****4444"`) {
+		t.Fatalf("custom masker not applied: %s", got)
+	}
+}
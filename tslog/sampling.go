@@ -0,0 +1,184 @@
+package tslog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultSamplingInterval = time.Second
+
+// SamplingConfig 日志采样配置，同一级别同一消息在每个周期内单独计数
+type SamplingConfig struct {
+	Interval   time.Duration           // 统计周期，默认 1 秒
+	Initial    int                     // 每个周期内先输出的条数，与 Thereafter 同时为 0 时不采样
+	Thereafter int                     // 超过 Initial 后每 Thereafter 条输出一条，0 表示全部丢弃
+	Levels     map[string]SamplingRule // 按级别覆盖 Initial/Thereafter，如 "error"，两者均为 0 时该级别不采样
+	Dedup      bool                    // 周期结束时输出被丢弃条数的汇总
+}
+
+// SamplingRule 单个级别的采样规则，Initial 和 Thereafter 均为 0 时不采样
+type SamplingRule struct {
+	Initial    int
+	Thereafter int
+}
+
+func (r SamplingRule) disabled() bool {
+	return r.Initial == 0 && r.Thereafter == 0
+}
+
+type samplingKey struct {
+	level zapcore.Level
+	msg   string
+}
+
+type samplingCounter struct {
+	count   int
+	dropped int
+	entry   zapcore.Entry // 第一条日志，用于汇总时的级别、名称和调用者
+}
+
+// sampler 各 samplingCore 共享的计数状态
+type sampler struct {
+	mu       sync.Mutex
+	core     zapcore.Core // 输出汇总日志的 core，不带子日志器字段
+	rules    map[zapcore.Level]SamplingRule
+	fallback SamplingRule
+	dedup    bool
+	counters map[samplingKey]*samplingCounter
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newSamplingCore 为 core 增加采样，启动周期计数重置及 Dedup 汇总，由 sampler.close 停止
+func newSamplingCore(core zapcore.Core, config *SamplingConfig) *samplingCore {
+	s := &sampler{
+		core:     core,
+		rules:    make(map[zapcore.Level]SamplingRule),
+		fallback: SamplingRule{Initial: config.Initial, Thereafter: config.Thereafter},
+		dedup:    config.Dedup,
+		counters: make(map[samplingKey]*samplingCounter),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for level, rule := range config.Levels {
+		if lvl, err := parseLevel(level); err == nil {
+			s.rules[lvl] = rule
+		}
+	}
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultSamplingInterval
+	}
+	go s.run(interval)
+	return &samplingCore{Core: core, sampler: s}
+}
+
+func (s *sampler) rule(level zapcore.Level) SamplingRule {
+	if rule, ok := s.rules[level]; ok {
+		return rule
+	}
+	return s.fallback
+}
+
+// allow 判断本条日志是否输出，Panic 及以上级别总是输出
+func (s *sampler) allow(entry zapcore.Entry) bool {
+	if entry.Level >= zapcore.DPanicLevel {
+		return true
+	}
+	rule := s.rule(entry.Level)
+	if rule.disabled() {
+		return true
+	}
+	key := samplingKey{level: entry.Level, msg: entry.Message}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &samplingCounter{entry: entry}
+		s.counters[key] = counter
+	}
+	counter.count++
+	if counter.count <= rule.Initial {
+		return true
+	}
+	if rule.Thereafter > 0 && (counter.count-rule.Initial)%rule.Thereafter == 0 {
+		return true
+	}
+	counter.dropped++
+	return false
+}
+
+// flush 结束当前周期，开启去重时为每个丢弃过日志的消息输出一条汇总
+func (s *sampler) flush() {
+	s.mu.Lock()
+	counters := s.counters
+	s.counters = make(map[samplingKey]*samplingCounter, len(counters))
+	s.mu.Unlock()
+
+	if !s.dedup {
+		return
+	}
+	for key, counter := range counters {
+		if counter.dropped == 0 {
+			continue
+		}
+		entry := counter.entry
+		entry.Time = time.Now()
+		entry.Message = fmt.Sprintf("%s (repeated %d times)", key.msg, counter.dropped)
+		entry.Stack = ""
+		if ce := s.core.Check(entry, nil); ce != nil {
+			ce.Write(zap.Int("repeated", counter.dropped))
+		}
+	}
+}
+
+func (s *sampler) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// close 停止周期 goroutine 并输出最后一个周期的汇总
+func (s *sampler) close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.flush()
+	})
+}
+
+// samplingCore 按级别和消息采样的 core
+type samplingCore struct {
+	zapcore.Core
+	sampler *sampler
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+func (c *samplingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) || !c.sampler.allow(entry) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// Sync 只刷新下层 core，汇总由周期定时器和 close 输出，避免频繁 Sync 打乱统计周期
+func (c *samplingCore) Sync() error {
+	return c.Core.Sync()
+}
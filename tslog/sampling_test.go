@@ -0,0 +1,101 @@
+package tslog
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newSampledLogger(t *testing.T, config *SamplingConfig) (*zap.Logger, *samplingCore, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	sampling := newSamplingCore(core, config)
+	t.Cleanup(sampling.sampler.close)
+	return zap.New(sampling), sampling, logs
+}
+
+func TestSamplingInitialThereafter(t *testing.T) {
+	logger, _, logs := newSampledLogger(t, &SamplingConfig{Interval: time.Hour, Initial: 2, Thereafter: 3})
+	for i := 0; i < 10; i++ {
+		logger.Info("hot")
+	}
+	logger.Info("other")
+	// 前 2 条，之后第 5、8 条，另一条消息单独计数
+	if got := logs.FilterMessage("hot").Len(); got != 4 {
+		t.Fatalf("hot logged %d times, want 4", got)
+	}
+	if got := logs.FilterMessage("other").Len(); got != 1 {
+		t.Fatalf("other logged %d times, want 1", got)
+	}
+}
+
+func TestSamplingZeroRuleDisablesSampling(t *testing.T) {
+	logger, _, logs := newSampledLogger(t, &SamplingConfig{
+		Interval: time.Hour,
+		Levels:   map[string]SamplingRule{"warn": {Initial: 1}},
+	})
+	for i := 0; i < 5; i++ {
+		logger.Info("info")
+		logger.Warn("warn")
+	}
+	// 默认规则为零值时不采样，只有 warn 按级别规则采样
+	if got := logs.FilterMessage("info").Len(); got != 5 {
+		t.Fatalf("info logged %d times, want 5", got)
+	}
+	if got := logs.FilterMessage("warn").Len(); got != 1 {
+		t.Fatalf("warn logged %d times, want 1", got)
+	}
+}
+
+func TestSamplingPanicLevelAlwaysLogged(t *testing.T) {
+	logger, _, logs := newSampledLogger(t, &SamplingConfig{Interval: time.Hour, Initial: 1})
+	for i := 0; i < 3; i++ {
+		logger.DPanic("dpanic")
+	}
+	if got := logs.Len(); got != 3 {
+		t.Fatalf("dpanic logged %d times, want 3", got)
+	}
+}
+
+func TestSamplingDedupSummary(t *testing.T) {
+	logger, sampling, logs := newSampledLogger(t, &SamplingConfig{Interval: time.Hour, Initial: 1, Dedup: true})
+	for i := 0; i < 4; i++ {
+		logger.Warn("hot")
+	}
+	// Sync 不输出汇总
+	logger.Sync()
+	if logs.FilterMessage("hot (repeated 3 times)").Len() != 0 {
+		t.Fatalf("Sync flushed summary: %+v", logs.All())
+	}
+	// 周期结束时输出汇总并重新计数
+	sampling.sampler.flush()
+	summaries := logs.FilterMessage("hot (repeated 3 times)").All()
+	if len(summaries) != 1 || summaries[0].Level != zapcore.WarnLevel || summaries[0].ContextMap()["repeated"] != int64(3) {
+		t.Fatalf("summaries = %+v", logs.All())
+	}
+	logger.Warn("hot")
+	if got := logs.FilterMessage("hot").Len(); got != 2 {
+		t.Fatalf("counter not reset after flush, hot logged %d times", got)
+	}
+
+	// 关闭时输出最后一个周期的汇总，且可重复调用
+	logger.Warn("hot")
+	sampling.sampler.close()
+	sampling.sampler.close()
+	if logs.FilterMessage("hot (repeated 1 times)").Len() != 1 {
+		t.Fatalf("no summary on close: %+v", logs.All())
+	}
+}
+
+func TestSamplingCloseStopsTicker(t *testing.T) {
+	_, sampling, _ := newSampledLogger(t, &SamplingConfig{Interval: time.Millisecond, Initial: 1})
+	sampling.sampler.close()
+	select {
+	case <-sampling.sampler.done:
+	default:
+		t.Fatal("ticker goroutine still running after close")
+	}
+}
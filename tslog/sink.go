@@ -0,0 +1,147 @@
+package tslog
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 输出目标类型
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"   // lumberjack 滚动文件
+	SinkSyslog = "syslog" // 通过 udp/tcp/unix socket 发送到 syslog
+	SinkHTTP   = "http"   // 批量 POST 到日志收集服务
+)
+
+// 编码格式
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+	EncodingLogfmt  = "logfmt"
+)
+
+// SinkConfig 单个输出目标的配置
+type SinkConfig struct {
+	Type     string // 输出目标类型: stdout, stderr, file, syslog, http
+	Level    string // 该目标的最低级别，为空不额外限制，如错误日志单独文件可设为 error
+	Encoding string // 编码格式: json, console, logfmt，为空时 debug 级别用 console，否则用 json
+
+	// file
+	Path       string // 文件路径
+	MaxSize    int    // 单个日志文件最大大小(MB)，为空使用 Config.MaxSize
+	MaxBackups int    // 保留的旧日志文件最大个数，为空使用 Config.MaxBackups
+	MaxAge     int    // 保留旧日志文件的最大天数，为空使用 Config.MaxAge
+
+	// syslog
+	Network string // udp, tcp, unix, unixgram
+	Address string // 如 127.0.0.1:514 或 /dev/log
+	Tag     string // syslog tag，默认为进程名
+
+	// http
+	URL           string            // 接收地址，请求体为换行分隔的日志
+	Headers       map[string]string // 附加请求头，如鉴权信息
+	BatchSize     int               // 攒够多少条发送一次，默认 100
+	FlushInterval time.Duration     // 最长发送间隔，默认 1 秒
+	Timeout       time.Duration     // 请求超时，默认 5 秒
+}
+
+// newEncoderConfig 默认编码器配置
+func newEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,    // 大写级别编码
+		EncodeTime:     zapcore.ISO8601TimeEncoder,     // ISO8601 时间格式
+		EncodeDuration: zapcore.SecondsDurationEncoder, // 秒为单位
+		EncodeCaller:   zapcore.ShortCallerEncoder,     // 短路径调用者
+	}
+}
+
+// newEncoder 按编码格式创建编码器
+func newEncoder(encoding string) (zapcore.Encoder, error) {
+	switch encoding {
+	case EncodingJSON:
+		return zapcore.NewJSONEncoder(newEncoderConfig()), nil
+	case EncodingConsole:
+		return zapcore.NewConsoleEncoder(newEncoderConfig()), nil
+	case EncodingLogfmt:
+		return newLogfmtEncoder(newEncoderConfig()), nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding: %q", encoding)
+	}
+}
+
+// newSinkCore 创建单个输出目标的 core，返回的关闭函数写出缓冲中的日志并释放文件、连接等资源，可能为 nil
+func newSinkCore(config *Config, sink SinkConfig, masker *fieldMasker) (zapcore.Core, func(), error) {
+	encoding := sink.Encoding
+	if encoding == "" {
+		encoding = EncodingJSON
+		if config.Level == "debug" {
+			encoding = EncodingConsole // 开发环境用控制台格式
+		}
+	}
+	encoder, err := newEncoder(encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+	encoder = withMasking(encoder, masker)
+
+	var level zapcore.LevelEnabler = zapcore.DebugLevel
+	if sink.Level != "" {
+		if level, err = parseLevel(sink.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var ws zapcore.WriteSyncer
+	var closeFn func()
+	switch sink.Type {
+	case SinkStdout, "":
+		ws = zapcore.Lock(os.Stdout)
+	case SinkStderr:
+		ws = zapcore.Lock(os.Stderr)
+	case SinkFile:
+		if sink.Path == "" {
+			return nil, nil, fmt.Errorf("file sink requires path")
+		}
+		if ws, closeFn, err = createFileWriter(config, sink); err != nil {
+			return nil, nil, err
+		}
+	case SinkSyslog:
+		// syslog 按条发送且需要日志级别，不经过异步缓冲
+		core, err := newSyslogCore(encoder, level, sink)
+		if err != nil {
+			return nil, nil, err
+		}
+		return core, core.writer.close, nil
+	case SinkHTTP:
+		if sink.URL == "" {
+			return nil, nil, fmt.Errorf("http sink requires url")
+		}
+		shipper := newHTTPShipper(sink)
+		ws, closeFn = shipper, shipper.close
+	default:
+		return nil, nil, fmt.Errorf("unknown log sink type: %q", sink.Type)
+	}
+	if config.Async != nil {
+		// 先停止异步写出并写完缓冲，再关闭底层写入器
+		core := newAsyncCore(encoder, ws, level, config.Async)
+		outClose := closeFn
+		return core, func() {
+			core.writer.close()
+			if outClose != nil {
+				outClose()
+			}
+		}, nil
+	}
+	return zapcore.NewCore(encoder, ws, level), closeFn, nil
+}
@@ -0,0 +1,73 @@
+package tslog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewSinkCoreErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sink SinkConfig
+	}{
+		{"unknown type", SinkConfig{Type: "kafka"}},
+		{"unknown encoding", SinkConfig{Type: SinkStdout, Encoding: "xml"}},
+		{"invalid level", SinkConfig{Type: SinkStdout, Level: "verbose"}},
+		{"file without path", SinkConfig{Type: SinkFile}},
+		{"http without url", SinkConfig{Type: SinkHTTP}},
+		{"syslog without address", SinkConfig{Type: SinkSyslog, Network: "udp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := newSinkCore(DefaultConfig(), tt.sink, nil); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestFileSinkLevelAndEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "error.log")
+	core, closeFn, err := newSinkCore(DefaultConfig(), SinkConfig{Type: SinkFile, Path: path, Level: "error", Encoding: EncodingLogfmt}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFn()
+	logger := zap.New(core)
+	logger.Info("info dropped")
+	logger.Error("db down", zap.String("host", "db1"))
+	logger.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `level=ERROR msg="db down" host=db1`) {
+		t.Fatalf("file = %q", data)
+	}
+}
+
+func TestConfigSinks(t *testing.T) {
+	config := DefaultConfig()
+	if sinks := config.sinks(); len(sinks) != 1 || sinks[0].Type != SinkStdout {
+		t.Fatalf("sinks = %+v", sinks)
+	}
+	config.LogPath = "/tmp/app.log"
+	if sinks := config.sinks(); len(sinks) != 2 || sinks[1].Type != SinkFile || sinks[1].Path != config.LogPath {
+		t.Fatalf("sinks = %+v", sinks)
+	}
+	config.Sinks = []SinkConfig{{Type: SinkStderr}}
+	if sinks := config.sinks(); len(sinks) != 1 || sinks[0].Type != SinkStderr {
+		t.Fatalf("configured sinks ignored: %+v", sinks)
+	}
+	if _, err := newEncoder(EncodingConsole); err != nil {
+		t.Fatal(err)
+	}
+	var _ zapcore.Encoder = newLogfmtEncoder(newEncoderConfig())
+}
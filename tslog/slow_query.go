@@ -0,0 +1,211 @@
+package tslog
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultSlowQueryThreshold  = 100 * time.Millisecond
+	defaultSlowQueryWindow     = time.Minute
+	defaultSlowQueryTopN       = 10
+	defaultSlowQueryMaxSamples = 1000
+)
+
+// SlowQueryConfig 慢查询统计配置
+type SlowQueryConfig struct {
+	Threshold  time.Duration // 慢查询阈值，默认 100ms
+	Window     time.Duration // 统计窗口，每个窗口结束时输出一次报告，默认 1 分钟
+	TopN       int           // 报告中按总耗时排序保留的指纹数，默认 10
+	MaxSamples int           // 每个指纹保留的耗时样本数，用于计算分位数，默认 1000
+}
+
+// QueryReport 单个 SQL 指纹在一个窗口内的统计
+type QueryReport struct {
+	Fingerprint string        `json:"fingerprint"`
+	Example     string        `json:"example"` // 窗口内最慢的一条
+	Count       int           `json:"count"`
+	Errors      int           `json:"errors"`
+	Total       time.Duration `json:"total"`
+	P50         time.Duration `json:"p50"`
+	P99         time.Duration `json:"p99"`
+	Max         time.Duration `json:"max"`
+	MaxRows     int64         `json:"maxRows"`
+}
+
+func (q QueryReport) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("fingerprint", q.Fingerprint)
+	enc.AddString("example", q.Example)
+	enc.AddInt("count", q.Count)
+	enc.AddInt("errors", q.Errors)
+	enc.AddDuration("total", q.Total)
+	enc.AddDuration("p50", q.P50)
+	enc.AddDuration("p99", q.P99)
+	enc.AddDuration("max", q.Max)
+	enc.AddInt64("maxRows", q.MaxRows)
+	return nil
+}
+
+type queryReports []QueryReport
+
+func (qs queryReports) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, q := range qs {
+		if err := enc.AppendObject(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type queryStats struct {
+	report  QueryReport
+	samples []time.Duration
+}
+
+// SlowQueryReporter 按 SQL 指纹聚合慢查询和出错的查询，定期输出耗时最多的 TopN
+// gorm 的 XLogger 和 xorm 的 XormLogger 共用同一个统计器即可统一报告
+type SlowQueryReporter struct {
+	config SlowQueryConfig
+	logger *Logger
+
+	mu    sync.Mutex
+	stats map[string]*queryStats
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// globalSlowQuery Init 时根据 Config.SlowQuery 创建的全局统计器
+var globalSlowQuery atomic.Pointer[SlowQueryReporter]
+
+// NewSlowQueryReporter 创建慢查询统计器并启动定期报告，logger 为空时使用 Default().Named("sql")
+func NewSlowQueryReporter(config SlowQueryConfig, logger *Logger) *SlowQueryReporter {
+	if config.Threshold <= 0 {
+		config.Threshold = defaultSlowQueryThreshold
+	}
+	if config.Window <= 0 {
+		config.Window = defaultSlowQueryWindow
+	}
+	if config.TopN <= 0 {
+		config.TopN = defaultSlowQueryTopN
+	}
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = defaultSlowQueryMaxSamples
+	}
+	if logger == nil {
+		logger = Default().Named("sql")
+	}
+	r := &SlowQueryReporter{
+		config: config,
+		logger: logger,
+		stats:  make(map[string]*queryStats),
+		stop:   make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Threshold 慢查询阈值
+func (r *SlowQueryReporter) Threshold() time.Duration {
+	return r.config.Threshold
+}
+
+// Observe 记录一次查询，未超过阈值且没有出错的查询不统计
+func (r *SlowQueryReporter) Observe(sql string, elapsed time.Duration, rows int64, err error) {
+	if err == nil && elapsed <= r.config.Threshold {
+		return
+	}
+	fingerprint := FingerprintSQL(sql)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[fingerprint]
+	if !ok {
+		stats = &queryStats{report: QueryReport{Fingerprint: fingerprint}}
+		r.stats[fingerprint] = stats
+	}
+	report := &stats.report
+	report.Count++
+	report.Total += elapsed
+	if err != nil {
+		report.Errors++
+	}
+	if elapsed >= report.Max {
+		report.Max = elapsed
+		report.Example = sql
+	}
+	if rows > report.MaxRows {
+		report.MaxRows = rows
+	}
+	// 蓄水池采样，样本数固定时各次查询被保留的概率相同
+	if len(stats.samples) < r.config.MaxSamples {
+		stats.samples = append(stats.samples, elapsed)
+	} else if i := rand.Intn(report.Count); i < r.config.MaxSamples {
+		stats.samples[i] = elapsed
+	}
+}
+
+// Report 结束当前窗口，返回按总耗时降序排列的 TopN 统计
+func (r *SlowQueryReporter) Report() []QueryReport {
+	r.mu.Lock()
+	stats := r.stats
+	r.stats = make(map[string]*queryStats, len(stats))
+	r.mu.Unlock()
+
+	reports := make([]QueryReport, 0, len(stats))
+	for _, s := range stats {
+		sort.Slice(s.samples, func(i, j int) bool { return s.samples[i] < s.samples[j] })
+		s.report.P50 = percentile(s.samples, 0.50)
+		s.report.P99 = percentile(s.samples, 0.99)
+		reports = append(reports, s.report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Total > reports[j].Total })
+	if len(reports) > r.config.TopN {
+		reports = reports[:r.config.TopN]
+	}
+	return reports
+}
+
+// percentile 已排序样本的分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+func (r *SlowQueryReporter) run() {
+	ticker := time.NewTicker(r.config.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.emit()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// emit 输出当前窗口的报告
+func (r *SlowQueryReporter) emit() {
+	reports := r.Report()
+	if len(reports) == 0 {
+		return
+	}
+	r.logger.Warn("slow query report", zap.Duration("window", r.config.Window), zap.Array("queries", queryReports(reports)))
+}
+
+// Close 停止定期报告并输出最后一个窗口的统计
+func (r *SlowQueryReporter) Close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		r.emit()
+	})
+}
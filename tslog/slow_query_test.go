@@ -0,0 +1,91 @@
+package tslog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestReporter(t *testing.T, config SlowQueryConfig) (*SlowQueryReporter, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	if config.Window == 0 {
+		config.Window = time.Hour
+	}
+	r := NewSlowQueryReporter(config, newLogger(zap.New(core)))
+	t.Cleanup(r.Close)
+	return r, logs
+}
+
+func TestSlowQueryReport(t *testing.T) {
+	r, _ := newTestReporter(t, SlowQueryConfig{Threshold: 10 * time.Millisecond, TopN: 2})
+	// 未超过阈值且没有出错的查询不统计
+	r.Observe("SELECT * FROM users WHERE id = 1", 5*time.Millisecond, 1, nil)
+	for i := 1; i <= 100; i++ {
+		r.Observe(fmt.Sprintf("SELECT * FROM users WHERE id = %d", i), time.Duration(10+i)*time.Millisecond, int64(i), nil)
+	}
+	r.Observe("UPDATE users SET age = 1", time.Millisecond, 0, errors.New("deadlock"))
+	r.Observe("DELETE FROM logs", 20*time.Millisecond, 0, nil)
+
+	reports := r.Report()
+	if len(reports) != 2 {
+		t.Fatalf("reports = %d, want TopN 2", len(reports))
+	}
+	users := reports[0]
+	if users.Fingerprint != "select * from users where id = ?" || users.Count != 100 || users.MaxRows != 100 {
+		t.Fatalf("users report = %+v", users)
+	}
+	if users.Max != 110*time.Millisecond || users.Example != "SELECT * FROM users WHERE id = 100" {
+		t.Fatalf("slowest example = %s %v", users.Example, users.Max)
+	}
+	if users.P50 != 60*time.Millisecond || users.P99 != 109*time.Millisecond {
+		t.Fatalf("p50 = %v, p99 = %v", users.P50, users.P99)
+	}
+	// 按总耗时降序，出错的查询即使很快也统计
+	if reports[1].Fingerprint != "delete from logs" {
+		t.Fatalf("second report = %+v", reports[1])
+	}
+
+	// 报告后开始新窗口
+	r.Observe("UPDATE users SET age = 1", time.Millisecond, 0, errors.New("deadlock"))
+	reports = r.Report()
+	if len(reports) != 1 || reports[0].Errors != 1 || reports[0].Count != 1 {
+		t.Fatalf("new window = %+v", reports)
+	}
+}
+
+func TestSlowQueryMaxSamples(t *testing.T) {
+	r, _ := newTestReporter(t, SlowQueryConfig{Threshold: time.Millisecond, MaxSamples: 10})
+	for i := 0; i < 1000; i++ {
+		r.Observe("SELECT 1", 2*time.Millisecond, 0, nil)
+	}
+	r.mu.Lock()
+	samples := len(r.stats["select ?"].samples)
+	r.mu.Unlock()
+	if samples != 10 {
+		t.Fatalf("samples = %d, want 10", samples)
+	}
+	if report := r.Report()[0]; report.Count != 1000 || report.Total != 2*time.Second {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestSlowQueryCloseEmits(t *testing.T) {
+	r, logs := newTestReporter(t, SlowQueryConfig{Threshold: time.Millisecond})
+	r.Observe("SELECT 1", time.Second, 1, nil)
+	r.Close()
+	r.Close()
+	entries := logs.FilterMessage("slow query report").All()
+	if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+		t.Fatalf("entries = %+v", logs.All())
+	}
+	queries, ok := entries[0].ContextMap()["queries"].([]interface{})
+	if !ok || len(queries) != 1 || queries[0].(map[string]interface{})["fingerprint"] != "select ?" {
+		t.Fatalf("queries = %v", entries[0].ContextMap()["queries"])
+	}
+}
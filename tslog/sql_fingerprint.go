@@ -0,0 +1,33 @@
+package tslog
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	fpStringRe = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"`)
+	fpNumberRe = regexp.MustCompile(`\b-?\d+(?:\.\d+)?\b`)
+	fpInListRe = regexp.MustCompile(`(?i)\bin\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fpValuesRe = regexp.MustCompile(`(?i)\bvalues\s*\([^)]*\)(?:\s*,\s*\([^)]*\))*`)
+	fpSpaceRe  = regexp.MustCompile(`\s+`)
+	fpGroupRe  = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fpBacktick = strings.NewReplacer("`", "")
+)
+
+// FingerprintSQL 将 SQL 归一化为指纹：字面量替换为 ?，IN 列表和多行 VALUES 折叠，
+// 去掉反引号、合并空白并转为小写，参数不同的同一条语句得到相同指纹
+func FingerprintSQL(sql string) string {
+	fp := fpStringRe.ReplaceAllString(sql, "?")
+	fp = fpNumberRe.ReplaceAllString(fp, "?")
+	fp = fpInListRe.ReplaceAllString(fp, "in (?+)")
+	fp = fpValuesRe.ReplaceAllStringFunc(fp, func(values string) string {
+		if group := fpGroupRe.FindString(values); group != "" {
+			return "values " + group + "+"
+		}
+		return values
+	})
+	fp = fpBacktick.Replace(fp)
+	fp = fpSpaceRe.ReplaceAllString(fp, " ")
+	return strings.ToLower(strings.TrimSpace(fp))
+}
@@ -0,0 +1,28 @@
+package tslog
+
+import "testing"
+
+func TestFingerprintSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM `users` WHERE id = 1", "select * from users where id = ?"},
+		{"SELECT * FROM users WHERE name = 'a''b' AND age > 3.5", "select * from users where name = ? and age > ?"},
+		{`SELECT * FROM users WHERE email = "a@b.com"`, "select * from users where email = ?"},
+		{"SELECT * FROM users WHERE id IN (1, 2, 3)", "select * from users where id in (?+)"},
+		{"select * from users where id in (7)", "select * from users where id in (?+)"},
+		{"INSERT INTO users (name, age) VALUES ('a', 1), ('b', 2)", "insert into users (name, age) values (?, ?)+"},
+		{"SELECT  *\n\tFROM users\nWHERE id=2  ", "select * from users where id=?"},
+		// 标识符中的数字不替换
+		{"SELECT col1 FROM t2", "select col1 from t2"},
+	}
+	for _, tt := range tests {
+		if got := FingerprintSQL(tt.sql); got != tt.want {
+			t.Errorf("FingerprintSQL(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+	if FingerprintSQL("SELECT * FROM t WHERE id IN (1,2)") != FingerprintSQL("select * from t where id in (3, 4, 5)") {
+		t.Fatal("IN lists of different length should share a fingerprint")
+	}
+}
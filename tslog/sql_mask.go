@@ -0,0 +1,153 @@
+package tslog
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// 列赋值，如 `pwd` = '123456'、email='a@b.com'
+	sqlAssignRe = regexp.MustCompile("(?i)([`\"]?(\\w+)[`\"]?\\s*=\\s*)('(?:[^'\\\\]|\\\\.|'')*'|\"(?:[^\"\\\\]|\\\\.)*\")")
+	// INSERT INTO t (c1, c2) VALUES (...), (...)
+	sqlInsertRe = regexp.MustCompile(`(?is)^(\s*INSERT\s+INTO\s+\S+\s*\()([^)]*)(\)\s*VALUES\s*)(.*)$`)
+	// 字符串字面量
+	sqlLiteralRe = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+)
+
+// MaskSQL 对 SQL 中的敏感参数脱敏：按列名规则处理赋值和 INSERT 的值，
+// 其余字符串字面量识别邮箱和手机号
+func MaskSQL(sql string) string {
+	m := activeMasker.Load()
+	if m == nil {
+		return sql
+	}
+	return m.maskSQL(sql)
+}
+
+func (m *fieldMasker) maskSQL(sql string) string {
+	sql = m.maskSQLInsert(sql)
+	sql = sqlAssignRe.ReplaceAllStringFunc(sql, func(match string) string {
+		sub := sqlAssignRe.FindStringSubmatch(match)
+		masker := m.ruleFor(sub[2])
+		if masker == nil {
+			return match
+		}
+		return sub[1] + maskSQLLiteral(sub[3], masker)
+	})
+	return sqlLiteralRe.ReplaceAllStringFunc(sql, func(literal string) string {
+		return maskSQLLiteral(literal, maskAuto)
+	})
+}
+
+// maskSQLInsert 按列名对 INSERT 的每一行值脱敏
+func (m *fieldMasker) maskSQLInsert(sql string) string {
+	sub := sqlInsertRe.FindStringSubmatch(sql)
+	if sub == nil {
+		return sql
+	}
+	columns := splitSQLList(sub[2])
+	maskerAt := make([]Masker, len(columns))
+	found := false
+	for i, column := range columns {
+		if maskerAt[i] = m.ruleFor(strings.Trim(strings.TrimSpace(column), "`\"")); maskerAt[i] != nil {
+			found = true
+		}
+	}
+	if !found {
+		return sql
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sub[1] + sub[2] + sub[3])
+	rest := sub[4]
+	for {
+		start := strings.IndexByte(rest, '(')
+		if start < 0 {
+			break
+		}
+		end := matchingParen(rest, start)
+		if end < 0 {
+			break
+		}
+		sb.WriteString(rest[:start+1])
+		values := splitSQLList(rest[start+1 : end])
+		for i, value := range values {
+			if i < len(maskerAt) && maskerAt[i] != nil {
+				trimmed := strings.TrimSpace(value)
+				values[i] = strings.Replace(value, trimmed, maskSQLLiteral(trimmed, maskerAt[i]), 1)
+			}
+		}
+		sb.WriteString(strings.Join(values, ","))
+		sb.WriteByte(')')
+		rest = rest[end+1:]
+	}
+	sb.WriteString(rest)
+	return sb.String()
+}
+
+// maskSQLLiteral 对带引号的字面量脱敏并保留引号，NULL 和数字按字符串处理
+func maskSQLLiteral(literal string, masker Masker) string {
+	if strings.EqualFold(literal, "NULL") {
+		return literal
+	}
+	if len(literal) >= 2 && (literal[0] == '\'' || literal[0] == '"') && literal[len(literal)-1] == literal[0] {
+		quote := literal[:1]
+		return quote + masker(literal[1:len(literal)-1]) + quote
+	}
+	return "'" + masker(literal) + "'"
+}
+
+// splitSQLList 按顶层逗号切分，忽略引号和括号内的逗号
+func splitSQLList(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// matchingParen 返回与 start 处左括号匹配的右括号位置
+func matchingParen(s string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
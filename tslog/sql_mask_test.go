@@ -0,0 +1,60 @@
+package tslog
+
+import "testing"
+
+func TestMaskSQL(t *testing.T) {
+	m := newFieldMasker(nil)
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			"update assignment",
+			"UPDATE users SET `pwd` = '123456', name='zhang' WHERE id = 1",
+			"UPDATE users SET `pwd` = '******', name='zhang' WHERE id = 1",
+		},
+		{
+			"where email",
+			`SELECT * FROM users WHERE email="zhang@163.com"`,
+			`SELECT * FROM users WHERE email="z***@163.com"`,
+		},
+		{
+			"insert multiple rows",
+			"INSERT INTO users (`name`, `password`, `phone`) VALUES ('a', 'p1', '13812345678'), ('b, c', 'p2', NULL)",
+			"INSERT INTO users (`name`, `password`, `phone`) VALUES ('a', '******', '138****5678'), ('b, c', '******', NULL)",
+		},
+		{
+			"insert without sensitive columns",
+			"INSERT INTO logs (msg) VALUES ('hello')",
+			"INSERT INTO logs (msg) VALUES ('hello')",
+		},
+		{
+			"auto detect literals",
+			"SELECT * FROM users WHERE contact IN ('zhang@163.com', '13812345678', 'x')",
+			"SELECT * FROM users WHERE contact IN ('z***@163.com', '138****5678', 'x')",
+		},
+		{
+			"escaped quote",
+			`UPDATE users SET token = 'a\'b' WHERE id = 1`,
+			`UPDATE users SET token = '******' WHERE id = 1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.maskSQL(tt.sql); got != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskSQLDisabled(t *testing.T) {
+	old := activeMasker.Load()
+	t.Cleanup(func() { activeMasker.Store(old) })
+	activeMasker.Store(nil)
+	sql := "UPDATE users SET pwd = '123456'"
+	if got := MaskSQL(sql); got != sql {
+		t.Fatalf("disabled masking changed sql: %s", got)
+	}
+}
@@ -0,0 +1,44 @@
+package tslog
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sqlTrace 一次 SQL 执行的日志规则，gorm 的 XLogger 和 xorm 的 XormLogger 共用，保证两者输出一致
+type sqlTrace struct {
+	logError      bool          // 输出出错的 SQL
+	logSlow       bool          // 输出慢 SQL
+	logAll        bool          // 以 debug 级别输出所有 SQL
+	slowThreshold time.Duration // 慢 SQL 阈值，0 表示不判断
+	reporter      *SlowQueryReporter
+}
+
+// log 输出 SQL 日志并记录慢查询统计，fc 只在需要时调用
+// 配置了慢查询统计时慢 SQL 只进入聚合报告，不再逐条输出
+func (t sqlTrace) log(logger *zap.Logger, elapsed time.Duration, fc func() (string, int64), err error) {
+	reporter := t.reporter
+	if reporter == nil {
+		reporter = globalSlowQuery.Load()
+	}
+	slow := t.slowThreshold != 0 && elapsed > t.slowThreshold
+	report := reporter != nil && (err != nil || elapsed > reporter.Threshold())
+	if !report && !(err != nil && t.logError) && !(slow && t.logSlow) && !t.logAll {
+		return
+	}
+
+	sql, rows := fc()
+	sql = MaskSQL(sql)
+	if report {
+		reporter.Observe(sql, elapsed, rows, err)
+	}
+	switch {
+	case err != nil && t.logError:
+		logger.Error("trace", zap.Error(err), zap.Duration("elapsed", elapsed), zap.Int64("rows", rows), zap.String("sql", sql))
+	case slow && t.logSlow && reporter == nil:
+		logger.Warn("trace", zap.Duration("elapsed", elapsed), zap.Int64("rows", rows), zap.String("sql", sql))
+	case t.logAll:
+		logger.Debug("trace", zap.Duration("elapsed", elapsed), zap.Int64("rows", rows), zap.String("sql", sql))
+	}
+}
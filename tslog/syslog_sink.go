@@ -0,0 +1,178 @@
+package tslog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslog facility local0
+const syslogFacility = 16
+
+// syslog 连接超时及重连退避，syslog 不可用期间的日志直接丢弃，不阻塞调用方
+const (
+	syslogDialTimeout = 2 * time.Second
+	syslogMinBackoff  = time.Second
+	syslogMaxBackoff  = time.Minute
+)
+
+// syslogWriter 通过 socket 发送 RFC 3164 格式的 syslog 消息，连接断开时按指数退避重连
+type syslogWriter struct {
+	mu        sync.Mutex
+	network   string
+	address   string
+	tag       string
+	hostname  string
+	conn      net.Conn
+	backoff   time.Duration // 当前退避时间，连接成功后清零
+	nextRetry time.Time     // 下次允许重连的时间，之前的日志直接丢弃
+	dropped   int           // 不可用期间丢弃的日志条数
+	closed    bool          // 关闭后丢弃日志，不再重连
+}
+
+func newSyslogCore(encoder zapcore.Encoder, level zapcore.LevelEnabler, sink SinkConfig) (*syslogCore, error) {
+	if sink.Network == "" || sink.Address == "" {
+		return nil, fmt.Errorf("syslog sink requires network and address")
+	}
+	tag := sink.Tag
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	hostname, _ := os.Hostname()
+	w := &syslogWriter{network: sink.Network, address: sink.Address, tag: tag, hostname: hostname}
+	// 启动时 syslog 不可用不影响初始化，之后按退避重连
+	if err := w.connect(); err != nil {
+		fmt.Fprintf(os.Stderr, "tslog: %v, will retry\n", err)
+	}
+	return &syslogCore{LevelEnabler: level, encoder: encoder, writer: w}, nil
+}
+
+// connect 建立连接，失败时推迟下次重连时间，调用方需持有 mu 或保证无并发
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.address, syslogDialTimeout)
+	if err != nil {
+		w.backoff = min(max(w.backoff*2, syslogMinBackoff), syslogMaxBackoff)
+		w.nextRetry = time.Now().Add(w.backoff)
+		return fmt.Errorf("failed to connect syslog %s://%s: %w", w.network, w.address, err)
+	}
+	w.conn = conn
+	w.backoff = 0
+	if w.dropped > 0 {
+		fmt.Fprintf(os.Stderr, "tslog: syslog %s://%s reconnected, %d entries dropped\n", w.network, w.address, w.dropped)
+		w.dropped = 0
+	}
+	return nil
+}
+
+// syslogSeverity zap 级别对应的 syslog severity
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func (w *syslogWriter) write(level zapcore.Level, msg []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+
+	// 流式连接需要换行分隔消息，数据报连接不需要
+	line := fmt.Sprintf("<%d>%s %s %s[%d]: %s", syslogFacility*8+syslogSeverity(level),
+		time.Now().Format(time.Stamp), w.hostname, w.tag, os.Getpid(), trimNewline(msg))
+	if w.network == "tcp" || w.network == "unix" {
+		line += "\n"
+	}
+	if w.conn != nil {
+		if _, err := w.conn.Write([]byte(line)); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	// 退避期间不重连，直接丢弃
+	if time.Now().Before(w.nextRetry) {
+		w.dropped++
+		return nil
+	}
+	if err := w.connect(); err != nil {
+		w.dropped++
+		return err
+	}
+	if _, err := w.conn.Write([]byte(line)); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+// close 关闭连接，之后的日志直接丢弃
+func (w *syslogWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+func trimNewline(msg []byte) []byte {
+	for len(msg) > 0 && (msg[len(msg)-1] == '\n' || msg[len(msg)-1] == '\r') {
+		msg = msg[:len(msg)-1]
+	}
+	return msg
+}
+
+// syslogCore 按日志级别设置 syslog severity 的 core
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslogWriter
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &syslogCore{LevelEnabler: c.LevelEnabler, encoder: encoder, writer: c.writer}
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	return c.writer.write(entry.Level, buf.Bytes())
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package tslog
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	core, err := newSyslogCore(newLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), zapcore.InfoLevel,
+		SinkConfig{Type: SinkSyslog, Network: "udp", Address: conn.LocalAddr().String(), Tag: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zap.New(core)
+	logger.Debug("filtered")
+	logger.Warn("disk full", zap.String("path", "/data"))
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0(16)*8 + warning(4)
+	line := string(buf[:n])
+	if !strings.HasPrefix(line, "<132>") || !strings.Contains(line, " app[") ||
+		!strings.HasSuffix(line, `: msg="disk full" path=/data`) {
+		t.Fatalf("line = %q", line)
+	}
+}
+
+func TestSyslogSinkBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// syslog 不可用时创建成功，之后按退避重连
+	core, err := newSyslogCore(newLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), zapcore.DebugLevel,
+		SinkConfig{Type: SinkSyslog, Network: "tcp", Address: addr, Tag: "app"})
+	if err != nil {
+		t.Fatalf("unreachable syslog failed init: %v", err)
+	}
+	w := core.writer
+	w.mu.Lock()
+	retryAt := w.nextRetry
+	w.mu.Unlock()
+	if retryAt.IsZero() {
+		t.Fatal("failed connect should schedule a retry")
+	}
+
+	// 退避期间直接丢弃，不重新拨号
+	logger := zap.New(core)
+	for i := 0; i < 3; i++ {
+		logger.Info("dropped")
+	}
+	w.mu.Lock()
+	if w.dropped != 3 || !w.nextRetry.Equal(retryAt) {
+		t.Fatalf("dropped = %d, nextRetry moved = %v", w.dropped, !w.nextRetry.Equal(retryAt))
+	}
+	w.nextRetry = time.Now()
+	w.mu.Unlock()
+
+	// 到期后重连仍失败，退避时间翻倍
+	logger.Info("still down")
+	w.mu.Lock()
+	if w.backoff != 2*time.Second || w.dropped != 4 {
+		t.Fatalf("backoff = %v, dropped = %d", w.backoff, w.dropped)
+	}
+	w.nextRetry = time.Now()
+	w.mu.Unlock()
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("port reused: %v", err)
+	}
+	defer ln.Close()
+	logger.Info("back")
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(server).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(line, ": msg=back\n") {
+		t.Fatalf("line = %q", line)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.backoff != 0 || w.dropped != 0 {
+		t.Fatalf("backoff = %v, dropped = %d after reconnect", w.backoff, w.dropped)
+	}
+}
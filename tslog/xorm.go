@@ -0,0 +1,184 @@
+package tslog
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	xormlog "xorm.io/xorm/log"
+)
+
+// XormLogger xorm 日志适配器，与 gorm 的 XLogger 使用相同的 SQL 日志和慢查询统计
+type XormLogger struct {
+	Logger        *Logger
+	SlowThreshold time.Duration
+	SlowQuery     *SlowQueryReporter // 慢查询统计，为空时使用 Config.SlowQuery 创建的全局统计器
+	Context       ContextFn
+	level         xormlog.LogLevel
+	showSQL       bool
+}
+
+var _ xormlog.ContextLogger = (*XormLogger)(nil)
+
+// NewXormLogger 创建 xorm 日志适配器，logger 为空时使用全局门面
+// 用法：engine.SetLogger(tslog.NewXormLogger(nil))
+func NewXormLogger(logger *Logger) *XormLogger {
+	if logger == nil {
+		logger = Default()
+	}
+	return &XormLogger{
+		Logger:        logger,
+		SlowThreshold: 100 * time.Millisecond,
+		Context:       contextFields,
+		level:         xormlog.LOG_INFO,
+	}
+}
+
+func (l *XormLogger) BeforeSQL(xormlog.LogContext) {}
+
+// AfterSQL 输出 SQL 日志，xorm 只在 IsShowSQL 为 true 时调用
+func (l *XormLogger) AfterSQL(ctx xormlog.LogContext) {
+	if l.level >= xormlog.LOG_OFF {
+		return
+	}
+	sqlTrace{
+		logError:      l.level <= xormlog.LOG_ERR,
+		logSlow:       l.level <= xormlog.LOG_WARNING,
+		logAll:        l.showSQL && l.level <= xormlog.LOG_INFO,
+		slowThreshold: l.SlowThreshold,
+		reporter:      l.SlowQuery,
+	}.log(l.logger(ctx.Ctx), ctx.ExecuteTime, func() (string, int64) {
+		rows := int64(-1)
+		if ctx.Result != nil {
+			if affected, err := ctx.Result.RowsAffected(); err == nil {
+				rows = affected
+			}
+		}
+		return interpolateSQL(ctx.SQL, ctx.Args), rows
+	}, ctx.Err)
+}
+
+func (l *XormLogger) Debugf(format string, v ...interface{}) {
+	l.Logger.load().sugar.Debugf(format, v...)
+}
+
+func (l *XormLogger) Infof(format string, v ...interface{}) {
+	l.Logger.load().sugar.Infof(format, v...)
+}
+
+func (l *XormLogger) Warnf(format string, v ...interface{}) {
+	l.Logger.load().sugar.Warnf(format, v...)
+}
+
+func (l *XormLogger) Errorf(format string, v ...interface{}) {
+	l.Logger.load().sugar.Errorf(format, v...)
+}
+
+func (l *XormLogger) Level() xormlog.LogLevel {
+	return l.level
+}
+
+func (l *XormLogger) SetLevel(level xormlog.LogLevel) {
+	l.level = level
+}
+
+// ShowSQL 控制是否输出所有 SQL，出错和慢 SQL 不受影响
+func (l *XormLogger) ShowSQL(show ...bool) {
+	l.showSQL = len(show) == 0 || show[0]
+}
+
+// IsShowSQL 除关闭日志外始终返回 true，保证出错和慢 SQL 能进入 AfterSQL
+func (l *XormLogger) IsShowSQL() bool {
+	return l.level < xormlog.LOG_OFF
+}
+
+// logger 附加 context 字段，调用者定位到 xorm 之外的第一个调用处
+func (l *XormLogger) logger(ctx context.Context) *zap.Logger {
+	logger := l.Logger.Zap()
+	if l.Context != nil && ctx != nil {
+		logger = logger.With(l.Context(ctx)...)
+	}
+	for i := 2; i < 30; i++ {
+		_, file, line, ok := runtime.Caller(i)
+		if !ok {
+			break
+		}
+		if strings.Contains(file, "xorm.io/") || strings.Contains(file, "/tslog/") ||
+			strings.Contains(file, "database/sql/") || strings.HasPrefix(file, runtime.GOROOT()) {
+			continue
+		}
+		return logger.WithOptions(zap.WithCaller(false)).With(zap.String("caller", trimCallerPath(file)+":"+strconv.Itoa(line)))
+	}
+	return logger.WithOptions(zap.WithCaller(false))
+}
+
+// trimCallerPath 保留最后两级路径，与 zap 的 ShortCallerEncoder 一致
+func trimCallerPath(file string) string {
+	idx := strings.LastIndexByte(file, '/')
+	if idx <= 0 {
+		return file
+	}
+	if idx = strings.LastIndexByte(file[:idx], '/'); idx < 0 {
+		return file
+	}
+	return file[idx+1:]
+}
+
+// interpolateSQL 将参数代入 ? 占位符，输出格式与 gorm 的 SQL 日志一致，便于脱敏和比对
+func interpolateSQL(sql string, args []interface{}) string {
+	if len(args) == 0 {
+		return sql
+	}
+	var sb strings.Builder
+	argIndex := 0
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && argIndex < len(args):
+			sb.WriteString(formatSQLArg(args[argIndex]))
+			argIndex++
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func formatSQLArg(arg interface{}) string {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "?"
+		}
+		arg = value
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "'" + strings.ReplaceAll(string(v), "'", "''") + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.000") + "'"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,129 @@
+package tslog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	xormlog "xorm.io/xorm/log"
+	"zyj.com/golang-study/define"
+)
+
+type rowsResult int64
+
+func (r rowsResult) LastInsertId() (int64, error) { return 0, nil }
+func (r rowsResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+type upperValuer string
+
+func (v upperValuer) Value() (driver.Value, error) { return "V:" + string(v), nil }
+
+func TestInterpolateSQL(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		sql  string
+		args []interface{}
+		want string
+	}{
+		{"SELECT 1", nil, "SELECT 1"},
+		{"SELECT * FROM t WHERE a = ? AND b = ?", []interface{}{1, "it's"}, "SELECT * FROM t WHERE a = 1 AND b = 'it''s'"},
+		{"UPDATE t SET a = ?, b = ?, c = ?", []interface{}{nil, true, at}, "UPDATE t SET a = NULL, b = true, c = '2024-01-02 03:04:05.000'"},
+		{"INSERT INTO t VALUES (?, ?)", []interface{}{[]byte("x"), upperValuer("y")}, "INSERT INTO t VALUES ('x', 'V:y')"},
+		// 引号内的 ? 不是占位符，多余的占位符原样保留
+		{"SELECT '?', `a?` FROM t WHERE a = ? AND b = ?", []interface{}{2}, "SELECT '?', `a?` FROM t WHERE a = 2 AND b = ?"},
+	}
+	for _, tt := range tests {
+		if got := interpolateSQL(tt.sql, tt.args); got != tt.want {
+			t.Errorf("interpolateSQL(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+	if got := trimCallerPath("/root/module/service/user.go"); got != "service/user.go" {
+		t.Fatalf("trimCallerPath = %q", got)
+	}
+}
+
+func newTestXormLogger(t *testing.T) (*XormLogger, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewXormLogger(newLogger(zap.New(core)))
+	l.SlowThreshold = 10 * time.Millisecond
+	return l, logs
+}
+
+func TestXormLoggerAfterSQL(t *testing.T) {
+	ctx := context.WithValue(context.Background(), define.HEADER_TRACE_ID_KEY, "t1")
+	fast := xormlog.LogContext{Ctx: ctx, SQL: "SELECT * FROM users WHERE id = ?", Args: []interface{}{1}, ExecuteTime: time.Millisecond, Result: rowsResult(1)}
+	slow := fast
+	slow.ExecuteTime = time.Second
+	failed := xormlog.LogContext{Ctx: ctx, SQL: "UPDATE users SET pwd = ? WHERE id = ?", Args: []interface{}{"123456", 1}, Err: errors.New("deadlock")}
+
+	tests := []struct {
+		name    string
+		level   xormlog.LogLevel
+		showSQL bool
+		ctx     xormlog.LogContext
+		want    zapcore.Level
+		logged  bool
+	}{
+		{"fast hidden", xormlog.LOG_INFO, false, fast, 0, false},
+		{"fast with show sql", xormlog.LOG_INFO, true, fast, zapcore.DebugLevel, true},
+		{"slow", xormlog.LOG_WARNING, false, slow, zapcore.WarnLevel, true},
+		{"slow below level", xormlog.LOG_ERR, false, slow, 0, false},
+		{"error", xormlog.LOG_ERR, false, failed, zapcore.ErrorLevel, true},
+		{"off", xormlog.LOG_OFF, true, failed, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := newTestXormLogger(t)
+			l.SetLevel(tt.level)
+			l.ShowSQL(tt.showSQL)
+			if l.IsShowSQL() != (tt.level < xormlog.LOG_OFF) {
+				t.Fatalf("IsShowSQL = %v", l.IsShowSQL())
+			}
+			l.AfterSQL(tt.ctx)
+			if !tt.logged {
+				if logs.Len() != 0 {
+					t.Fatalf("unexpected entries %+v", logs.All())
+				}
+				return
+			}
+			if logs.Len() != 1 || logs.All()[0].Level != tt.want {
+				t.Fatalf("entries = %+v", logs.All())
+			}
+			fields := logs.All()[0].ContextMap()
+			if fields["trace_id"] != "t1" {
+				t.Fatalf("context fields missing: %v", fields)
+			}
+			if tt.ctx.Err != nil {
+				// 参数代入后按列名脱敏，出错时行数未知
+				if fields["sql"] != "UPDATE users SET pwd = '******' WHERE id = 1" || fields["rows"] != int64(-1) {
+					t.Fatalf("fields = %v", fields)
+				}
+			} else if fields["sql"] != "SELECT * FROM users WHERE id = 1" || fields["rows"] != int64(1) {
+				t.Fatalf("fields = %v", fields)
+			}
+		})
+	}
+}
+
+func TestXormLoggerSlowQueryReporter(t *testing.T) {
+	l, logs := newTestXormLogger(t)
+	reporter, _ := newTestReporter(t, SlowQueryConfig{Threshold: 10 * time.Millisecond})
+	l.SlowQuery = reporter
+	l.SetLevel(xormlog.LOG_WARNING)
+
+	// 配置了慢查询统计时慢 SQL 只进入聚合报告
+	l.AfterSQL(xormlog.LogContext{Ctx: context.Background(), SQL: "SELECT * FROM users WHERE id = ?", Args: []interface{}{7}, ExecuteTime: time.Second})
+	if logs.Len() != 0 {
+		t.Fatalf("slow sql logged individually: %+v", logs.All())
+	}
+	reports := reporter.Report()
+	if len(reports) != 1 || reports[0].Example != "SELECT * FROM users WHERE id = 7" {
+		t.Fatalf("reports = %+v", reports)
+	}
+}
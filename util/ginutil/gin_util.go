@@ -2,9 +2,11 @@ package ginutil
 
 import (
 	"context"
-	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"zyj.com/golang-study/api"
 	"zyj.com/golang-study/define"
 	"zyj.com/golang-study/pkg/tserror"
@@ -39,34 +41,60 @@ func AppendContextDebugMsg(c *gin.Context, msg string) {
 	}
 }
 
-func Response(ctx *gin.Context, respObj interface{}, err error) {
+// always200 返回 true 的请求出错时也返回 200，错误码只在响应体和 Code 响应头中
+var always200 atomic.Pointer[func(c *gin.Context) bool]
+
+// SetAlways200Func 开启"始终返回 200"模式，fn 判断请求是否适用，为 nil 时关闭
+// 如只对移动端开启：ginutil.SetAlways200Func(ginutil.IsMobileClient)
+func SetAlways200Func(fn func(c *gin.Context) bool) {
+	if fn == nil {
+		always200.Store(nil)
+		return
+	}
+	always200.Store(&fn)
+}
 
-	result := api.NewResult(respObj, err)
+// IsMobileClient 是否为移动端请求
+func IsMobileClient(c *gin.Context) bool {
+	platform := strings.ToLower(c.GetHeader(define.AppPlatform))
+	return platform == define.Ios || platform == define.Android
+}
+
+func isAlways200(c *gin.Context) bool {
+	fn := always200.Load()
+	return fn != nil && (*fn)(c)
+}
+
+// Response 输出统一响应，成功时返回 200 和数据，失败时按错误码映射 HTTP 状态，
+// 并按 Accept 选择 Result 或 problem+json 格式
+func Response(ctx *gin.Context, respObj interface{}, err error) {
+	result := api.NewResultWithContext(ctx, respObj, err)
 	ctx.Set(define.RESPONSE_ERROR_CODE, result.Code)
-	if err != nil {
-		ctx.Set(define.RESPONSE_ERROR_DETAIL_MSG, err.Error())
+	if err == nil {
+		ctx.JSON(http.StatusOK, result)
+		return
 	}
-	var e *tserror.BizError
-	if errors.As(err, &e) {
-		if len(e.Message) == 0 {
-			ctx.Set(define.RESPONSE_ERROR_DETAIL_MSG, err.Error())
-		}
-		if result.Code == http.StatusUnauthorized {
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-		} else {
-			ctx.JSON(http.StatusBadRequest, result)
-		}
-	} else {
-		ctx.Set(define.RESPONSE_ERROR_STACK, result.Stack)
-		ctx.JSON(http.StatusInternalServerError, result)
+
+	ctx.Set(define.RESPONSE_ERROR_DETAIL_MSG, err.Error())
+	ctx.Header(define.RESPONSE_HEADER_ERROR_CODE, strconv.Itoa(int(result.Code)))
+	// 生产模式下响应不含调用栈，但仍记录到访问日志
+	ctx.Set(define.RESPONSE_ERROR_STACK, api.ErrorStack(err))
+	status := tserror.HttpStatusOf(err)
+	if status == http.StatusUnauthorized {
+		ctx.Abort()
+	}
+	if isAlways200(ctx) {
+		ctx.JSON(http.StatusOK, result)
+		return
 	}
+	api.Render(ctx, status, result)
 }
 
 func GetCheeseID(c *gin.Context) string {
 	return c.GetHeader("Cheese-ID")
 }
 func GetUserID(c *gin.Context) string {
-	return c.GetHeader("User-ID")
+	return c.GetHeader(define.HEADER_USER_ID_KEY)
 }
 
 func GetHeaderTs(c *gin.Context) string {
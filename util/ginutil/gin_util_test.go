@@ -0,0 +1,108 @@
+package ginutil
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"zyj.com/golang-study/define"
+	"zyj.com/golang-study/pkg/tserror"
+)
+
+func newTestContext(header map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	for key, value := range header {
+		c.Request.Header.Set(key, value)
+	}
+	return c, w
+}
+
+func TestResponseRecordsStackForEveryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"biz error", tserror.NewBizErrCode(tserror.CodeUserNotFound, "")},
+		{"coded 4xx", tserror.New(tserror.CodeParamInvalid, "")},
+		{"system error", tserror.NewSystemError("db", errors.New("refused"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(nil)
+			Response(c, nil, tt.err)
+			if stack := c.GetString(define.RESPONSE_ERROR_STACK); !strings.Contains(stack, "gin_util_test.go") {
+				t.Fatalf("stack = %q", stack)
+			}
+			if c.GetString(define.RESPONSE_ERROR_DETAIL_MSG) != tt.err.Error() {
+				t.Fatalf("detail = %q", c.GetString(define.RESPONSE_ERROR_DETAIL_MSG))
+			}
+		})
+	}
+
+	// 没有调用栈的错误记录错误信息
+	c, _ := newTestContext(nil)
+	Response(c, nil, errors.New("boom"))
+	if stack := c.GetString(define.RESPONSE_ERROR_STACK); stack != "boom" {
+		t.Fatalf("stack = %q", stack)
+	}
+
+	c, _ = newTestContext(nil)
+	Response(c, "ok", nil)
+	if _, exists := c.Get(define.RESPONSE_ERROR_STACK); exists {
+		t.Fatal("success response should not record a stack")
+	}
+}
+
+func TestResponseStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		err    error
+		status int
+	}{
+		{"success", nil, nil, http.StatusOK},
+		{"not found", nil, tserror.New(tserror.CodeUserNotFound, ""), http.StatusNotFound},
+		{"too many requests", nil, tserror.NewBizErrCode(tserror.CodeTooManyRequests, ""), http.StatusTooManyRequests},
+		{"service unavailable", nil, tserror.New(tserror.CodeServiceUnavailable, ""), http.StatusServiceUnavailable},
+		{"biz error never 5xx", nil, tserror.NewBizErrCode(tserror.CodeServiceUnavailable, ""), http.StatusBadRequest},
+		{"mobile without always-200", map[string]string{define.AppPlatform: define.Ios}, tserror.New(tserror.CodeUserNotFound, ""), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestContext(tt.header)
+			Response(c, nil, tt.err)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestResponseAlways200(t *testing.T) {
+	SetAlways200Func(IsMobileClient)
+	t.Cleanup(func() { SetAlways200Func(nil) })
+
+	// 移动端出错时返回 200，错误码在响应体和 Code 响应头中
+	c, w := newTestContext(map[string]string{define.AppPlatform: "Android", "Accept": "application/problem+json"})
+	Response(c, nil, tserror.New(tserror.CodeUnauthorized, ""))
+	if w.Code != http.StatusOK || w.Header().Get(define.RESPONSE_HEADER_ERROR_CODE) != "40003" ||
+		!strings.Contains(w.Body.String(), `"code":40003`) || strings.Contains(w.Header().Get("Content-Type"), "problem") {
+		t.Fatalf("mobile response = %d %v %s", w.Code, w.Header(), w.Body)
+	}
+	if !c.IsAborted() {
+		t.Fatal("401 should abort even in always-200 mode")
+	}
+
+	// 其他客户端仍按错误码映射
+	c, w = newTestContext(map[string]string{define.AppPlatform: "web"})
+	Response(c, nil, tserror.New(tserror.CodeUnauthorized, ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("web status = %d", w.Code)
+	}
+}
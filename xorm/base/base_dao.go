@@ -4,13 +4,14 @@ package base
 import (
 	"errors"
 	"xorm.io/xorm"
+	"zyj.com/golang-study/pkg/tserror"
 	"zyj.com/golang-study/util/validator"
 	"zyj.com/golang-study/xorm/base/database"
 	"zyj.com/golang-study/xorm/param"
 	"zyj.com/golang-study/xorm/result"
 )
 
-// BaseDAO 基础DAO
+// BaseDAO 基础DAO，数据库错误统一经 tserror.TranslateDBError 转换为带错误码的错误
 type BaseDAO[T any, K any] struct {
 }
 
@@ -26,10 +27,10 @@ func (bd *BaseDAO[T, K]) GetByID(session *xorm.Session, id K) (*T, error) {
 	var entity T
 	has, err := session.ID(id).Get(&entity)
 	if err != nil {
-		return nil, err
+		return nil, tserror.TranslateDBError(err)
 	}
 	if !has {
-		return nil, errors.New("entity not found")
+		return nil, tserror.TranslateDBError(tserror.ErrEntityNotFound)
 	}
 	return &entity, nil
 }
@@ -41,32 +42,37 @@ func (bd *BaseDAO[T, K]) UpdateById(session *xorm.Session, id K, entity *T) (int
 		return 0, err
 	}
 	count, err := session.ID(id).Update(entity)
-	return count, err
+	return count, tserror.TranslateDBError(err)
 }
 
 // UpdateUserById 更新用户
 func (bd *BaseDAO[T, K]) BatchUpdateByIds(session *xorm.Session, ids []K, entity *T) (int64, error) {
-	err := validator.IsEmpty(entity, "更新用户数据不能为空")
-	err2 := validator.IsEmpty(ids, "更新用户ID列表不能为空")
-	key, err3 := database.GetPrimaryKey[T]()
-	count, er4 := session.In(key, ids).Update(entity)
-	return count, errors.Join(err, err2, err3, er4)
+	err := errors.Join(validator.IsEmpty(entity, "更新用户数据不能为空"), validator.IsEmpty(ids, "更新用户ID列表不能为空"))
+	if err != nil {
+		return 0, err
+	}
+	key, err := database.GetPrimaryKey[T]()
+	if err != nil {
+		return 0, err
+	}
+	count, err := session.In(key, ids).Update(entity)
+	return count, tserror.TranslateDBError(err)
 }
 
 // DeleteById 删除用户
 func (bd *BaseDAO[T, K]) DeleteById(session *xorm.Session, id int64, entity *T) error {
 	_, err := session.ID(id).Delete(entity)
-	return err
+	return tserror.TranslateDBError(err)
 }
 
 func (bd *BaseDAO[T, K]) Insert(session *xorm.Session, entity *T) error {
 	_, err := session.Insert(entity)
-	return err
+	return tserror.TranslateDBError(err)
 }
 
 func (bd *BaseDAO[T, K]) BatchInsert(session *xorm.Session, entitys *[]T) error {
 	_, err := session.InsertMulti(entitys)
-	return err
+	return tserror.TranslateDBError(err)
 }
 
 func (bd *BaseDAO[T, K]) Page(session *xorm.Session, param *param.PageParam) (*result.PageVO[T], error) {
@@ -79,24 +85,28 @@ func (bd *BaseDAO[T, K]) Page(session *xorm.Session, param *param.PageParam) (*r
 	var entities []T
 	err := session.Limit(param.PageSize, param.PageSize*(param.Page-1)).Find(&entities)
 	if err != nil {
-		return &result.PageVO[T]{}, err
+		return &result.PageVO[T]{}, tserror.TranslateDBError(err)
 	}
 	var t T
 	count, err := session.Count(t)
 	if err != nil {
-		return &result.PageVO[T]{}, err
+		return &result.PageVO[T]{}, tserror.TranslateDBError(err)
 	}
 	return result.Convert2PageVO[T](param, count, entities), nil
 }
 
 // Count 统计数量
 func (bd *BaseDAO[T, k]) Count(session *xorm.Session, entity *T) (int64, error) {
-	return session.Count(entity)
+	count, err := session.Count(entity)
+	return count, tserror.TranslateDBError(err)
 }
 
 func (bd *BaseDAO[T, K]) ListByIds(session *xorm.Session, ids []K) ([]T, error) {
 	var entities []T
 	key, err := database.GetPrimaryKey[T]()
+	if err != nil {
+		return nil, err
+	}
 	err = session.In(key, ids).Find(&entities)
-	return entities, err
+	return entities, tserror.TranslateDBError(err)
 }
@@ -0,0 +1,29 @@
+package base
+
+import "testing"
+
+type daoTestEntity struct {
+	Id   int64
+	Name string
+}
+
+func TestBatchUpdateByIdsValidatesFirst(t *testing.T) {
+	dao := &BaseDAO[daoTestEntity, int64]{}
+	// 校验失败时直接返回，不访问 session
+	tests := []struct {
+		name   string
+		ids    []int64
+		entity *daoTestEntity
+	}{
+		{"empty ids", nil, &daoTestEntity{Name: "a"}},
+		{"nil entity", []int64{1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := dao.BatchUpdateByIds(nil, tt.ids, tt.entity)
+			if err == nil || count != 0 {
+				t.Fatalf("count = %d, err = %v", count, err)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 	"xorm.io/xorm"
+	"zyj.com/golang-study/tslog"
 	"zyj.com/golang-study/util/varutil"
 )
 
@@ -26,6 +27,8 @@ var (
 
 	sessionMap    = xsync.NewMapOf[int64, *xorm.Session]()
 	idKeyTableMap = xsync.NewMapOf[string, string]()
+	// 事务提交后执行的回调，按 goroutine Id 存放
+	afterCommitMap = xsync.NewMapOf[int64, []func()]()
 )
 
 // Init 初始化数据库连接
@@ -43,7 +46,8 @@ func Init(driver, dsn string) error {
 		engine.SetMaxOpenConns(100)
 		engine.SetMaxIdleConns(10)
 		engine.SetConnMaxLifetime(time.Hour)
-		// 显示SQL日志（开发环境）
+		// SQL日志通过 tslog 输出，显示所有SQL（开发环境）
+		engine.SetLogger(tslog.NewXormLogger(nil))
 		engine.ShowSQL(true)
 		//group, err := xorm.NewEngineGroup("postgres", nil)
 		//group.Master()
@@ -123,9 +127,10 @@ func WithTransaction(fn func() (err error)) (err error) {
 	}
 	if err := fn(); err != nil {
 		err2 := session.Rollback()
+		afterCommitMap.Delete(goid.Get())
 		return errors.Join(err, err2)
 	}
-	return session.Commit()
+	return commitAndRunHooks(session)
 }
 
 func WithTransactionSession(fn func(session *xorm.Session) (err error)) (err error) {
@@ -138,9 +143,41 @@ func WithTransactionSession(fn func(session *xorm.Session) (err error)) (err err
 	}
 	if err := fn(session); err != nil {
 		err2 := session.Rollback()
+		afterCommitMap.Delete(goid.Get())
 		return errors.Join(err, err2)
 	}
-	return session.Commit()
+	return commitAndRunHooks(session)
+}
+
+// InTransaction 当前 goroutine 的会话是否处于事务中
+func InTransaction() bool {
+	session, ok := sessionMap.Load(goid.Get())
+	return ok && session.IsInTx()
+}
+
+// AfterCommit 注册事务提交后执行的回调（如缓存失效）
+// 当前 goroutine 的会话不在事务中时立即执行；事务回滚时回调被丢弃
+func AfterCommit(fn func()) {
+	if !InTransaction() {
+		fn()
+		return
+	}
+	afterCommitMap.Compute(goid.Get(), func(hooks []func(), loaded bool) ([]func(), bool) {
+		return append(hooks, fn), false
+	})
+}
+
+// commitAndRunHooks 提交事务，成功后执行 AfterCommit 注册的回调
+func commitAndRunHooks(session *xorm.Session) error {
+	if err := session.Commit(); err != nil {
+		afterCommitMap.Delete(goid.Get())
+		return err
+	}
+	hooks, _ := afterCommitMap.LoadAndDelete(goid.Get())
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
 }
 
 func GetPrimaryKey[T any]() (string, error) {
@@ -168,6 +205,33 @@ func GetPrimaryKey[T any]() (string, error) {
 	return "", errors.New("no primary key found")
 }
 
+// GetTableName 获取实体对应的表名
+func GetTableName[T any]() (string, error) {
+	var t T
+	table, err := getEngine().TableInfo(t)
+	if err != nil {
+		return "", err
+	}
+	return table.Name, nil
+}
+
+// GetPrimaryKeyValue 获取实体主键字段的值
+func GetPrimaryKeyValue[T any](entity *T) (interface{}, error) {
+	table, err := getEngine().TableInfo(entity)
+	if err != nil {
+		return nil, err
+	}
+	columns := table.PKColumns()
+	if len(columns) == 0 {
+		return nil, errors.New("no primary key found")
+	}
+	value, err := columns[0].ValueOf(entity)
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
 func QueryRowsBySql[T any](session *xorm.Session, sql string) ([]T, error) {
 	exec, err := session.QueryInterface(sql)
 	if err != nil {
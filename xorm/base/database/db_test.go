@@ -0,0 +1,99 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/petermattis/goid"
+	"xorm.io/xorm"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "database")
+	if err != nil {
+		panic(err)
+	}
+	if err := Init("sqlite3", filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	CloseEngine()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestAfterCommitRunsImmediatelyOutsideTransaction(t *testing.T) {
+	ran := false
+	AfterCommit(func() { ran = true })
+	if !ran {
+		t.Fatal("hook should run immediately outside a transaction")
+	}
+}
+
+func TestAfterCommitRunsAfterCommit(t *testing.T) {
+	ran := false
+	err := WithTransaction(func() error {
+		if !InTransaction() {
+			t.Fatal("should be in transaction")
+		}
+		AfterCommit(func() { ran = true })
+		if ran {
+			t.Fatal("hook ran before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Fatal("hook did not run after commit")
+	}
+	if InTransaction() {
+		t.Fatal("should not be in transaction after commit")
+	}
+}
+
+func TestAfterCommitDroppedOnRollback(t *testing.T) {
+	ran := false
+	errRollback := errors.New("rollback")
+	err := WithTransactionSession(func(session *xorm.Session) error {
+		AfterCommit(func() { ran = true })
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("got %v", err)
+	}
+	if ran {
+		t.Fatal("hook ran after rollback")
+	}
+	if _, ok := afterCommitMap.Load(goid.Get()); ok {
+		t.Fatal("hooks of rolled back transaction should be dropped")
+	}
+
+	// 回滚丢弃的回调不会在下一个事务提交时执行
+	if err := WithTransaction(func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if ran {
+		t.Fatal("dropped hook ran on next commit")
+	}
+}
+
+func TestCommitAndRunHooksKeepsOrder(t *testing.T) {
+	var order []int
+	err := WithTransaction(func() error {
+		for i := 0; i < 3; i++ {
+			AfterCommit(func() { order = append(order, i) })
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[0] != 0 || order[1] != 1 || order[2] != 2 {
+		t.Fatalf("hooks order: %v", order)
+	}
+}
@@ -2,8 +2,8 @@
 package dao
 
 import (
-	"errors"
 	"xorm.io/xorm"
+	"zyj.com/golang-study/pkg/tserror"
 	"zyj.com/golang-study/xorm/base"
 	"zyj.com/golang-study/xorm/model"
 )
@@ -13,6 +13,11 @@ type UserDAO struct {
 	*base.BaseDAO[model.User, int64]
 }
 
+func init() {
+	// users.email 唯一索引冲突即用户已存在
+	tserror.RegisterDuplicateKey("UQE_users_email", tserror.CodeUserExist)
+}
+
 // 全局用户DAO实例
 var UserDaoIns = &UserDAO{&base.BaseDAO[model.User, int64]{}}
 
@@ -24,7 +29,7 @@ func (ud *UserDAO) CreateUser(session *xorm.Session, user *model.User) error {
 		return err
 	}
 	if exist {
-		return errors.New("email already exists")
+		return tserror.New(tserror.CodeUserExist, "").With("email", user.Email)
 	}
 	return ud.BaseDAO.Insert(session, user)
 }
@@ -34,10 +39,10 @@ func (ud *UserDAO) GetByEmail(session *xorm.Session, email string) (*model.User,
 	var user model.User
 	has, err := session.Where("email = ?", email).Get(&user)
 	if err != nil {
-		return nil, err
+		return nil, tserror.TranslateDBError(err)
 	}
 	if !has {
-		return nil, errors.New("user not found")
+		return nil, tserror.Wrap(tserror.ErrEntityNotFound, tserror.CodeUserNotFound, "")
 	}
 	return &user, nil
 }
@@ -56,7 +61,7 @@ func (ud *UserDAO) UpdateUserById(session *xorm.Session, user *model.User) error
 			return err
 		}
 		if exist {
-			return errors.New("email already used by another user")
+			return tserror.New(tserror.CodeUserExist, "").With("email", user.Email, "user_id", user.Id)
 		}
 	}
 	//obj.CopyToObj(user, existing)
@@ -8,7 +8,7 @@ type User struct {
 	Name      string    `xorm:"'name' varchar(100) notnull" json:"name"`
 	Email     string    `xorm:"'email' varchar(100) notnull unique" json:"email"`
 	Age       int       `xorm:"'age' int" json:"age"`
-	Pwd       string    `xorm:"'pwd' varchar(255)" json:"pwd"`
+	Pwd       string    `xorm:"'pwd' varchar(255)" json:"pwd" log:"mask"`
 	Status    int       `xorm:"'status' int default 1" json:"status"`
 	CreatedAt time.Time `xorm:"'created_at' created" json:"createdAt"`
 	UpdatedAt time.Time `xorm:"'updated_at' updated" json:"updatedAt"`
@@ -17,13 +17,13 @@ type UserUpdate struct {
 	Name   string ` json:"name"`
 	Email  string ` json:"email"`
 	Age    int    ` json:"age"`
-	Pwd    string `json:"pwd"`
+	Pwd    string `json:"pwd" log:"mask"`
 	Status int    ` json:"status"`
 }
 
 type UserLogin struct {
 	Email string `json:"email" form:"email"`
-	Pwd   string `json:"pwd" form:"pwd"`
+	Pwd   string `json:"pwd" form:"pwd" log:"mask"`
 }
 
 func ConvertToModel(user *UserCreate) *model.User {